@@ -28,10 +28,14 @@ for lib in ${LIBS[@]}; do
     $GO get $lib
 done
 
-target="demo"
-printf "==== %4s build start ====\n" "$target"
-cd "$GOPATH/src/$target"
-$GO build -o "../../$OUTDIR/$target" -v
-printf "==== %4s build end ====\n" "$target"
+TARGETS=("demo"
+         "signer")
+
+for target in ${TARGETS[@]}; do
+    printf "==== %4s build start ====\n" "$target"
+    cd "$GOPATH/src/$target"
+    $GO build -o "../../$OUTDIR/$target" -v
+    printf "==== %4s build end ====\n" "$target"
+done
 
 echo "===== buid end ===="
@@ -30,6 +30,7 @@ func listCmds() []*cmd {
 	list = append(list, &cmd{[]string{"balance", "b"}, balance})
 	list = append(list, &cmd{[]string{"fee"}, txfee})
 	list = append(list, &cmd{[]string{"faucet"}, faucet})
+	list = append(list, &cmd{[]string{"bond"}, bond})
 	return list
 }
 
@@ -140,6 +141,77 @@ func faucet(args []string, d *Demo) error {
 	return nil
 }
 
+func bond(args []string, d *Demo) error {
+	var err error
+	satoshi := int(1 * btcutil.SatoshiPerBitcoin)
+	if len(args) > 1 {
+		satoshi, err = strconv.Atoi(args[1])
+		if err != nil {
+			return err
+		}
+	}
+	if satoshi < 1 {
+		return fmt.Errorf("satoshi is less than or equal to zero. %d", satoshi)
+	}
+	blocks := 1000
+	if len(args) > 2 {
+		blocks, err = strconv.Atoi(args[2])
+		if err != nil {
+			return err
+		}
+	}
+	if blocks < 1 {
+		return fmt.Errorf("blocks is less than or equal to zero. %d", blocks)
+	}
+	res, err := d.rpc.Request("getblockcount")
+	if err != nil {
+		return err
+	}
+	count, _ := res.Result.(float64)
+	locktime := uint32(int(count) + blocks)
+	adr, err := d.olivia.BondAddress(locktime)
+	if err != nil {
+		return err
+	}
+	res, err = d.rpc.Request("sendtoaddress", adr, float64(satoshi)/btcutil.SatoshiPerBitcoin)
+	if err != nil {
+		return err
+	}
+	txid, _ := res.Result.(string)
+	_, err = d.rpc.Request("generate", 1)
+	if err != nil {
+		return err
+	}
+	res, err = d.rpc.Request("getrawtransaction", txid)
+	if err != nil {
+		return err
+	}
+	str, _ := res.Result.(string)
+	bs, err := hex.DecodeString(str)
+	if err != nil {
+		return err
+	}
+	tx, err := bsToMsgTx(bs)
+	if err != nil {
+		return err
+	}
+	pkScript, err := d.olivia.BondPkScript(locktime)
+	if err != nil {
+		return err
+	}
+	for vout, txout := range tx.TxOut {
+		if bytes.Equal(txout.PkScript, pkScript) {
+			err = d.olivia.SetBond(txid, uint32(vout), locktime)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("oracle bond %s:%d %d satoshi locktime:%d\n", txid, vout, satoshi, locktime)
+			return nil
+		}
+	}
+	return fmt.Errorf("bond output not found : %s", txid)
+}
+
 func txfee(args []string, d *Demo) error {
 	if len(args) < 2 {
 		return fmt.Errorf("illegal parameter")
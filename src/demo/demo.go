@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -73,17 +74,26 @@ func initial() (*Demo, error) {
 
 	params := chaincfg.RegressionNetParams
 	// Olivia (Oracle)
-	d.olivia, err = oracle.NewOracle("Olivia", params, d.rpc)
-	if err != nil {
-		return nil, err
+	// If ORACLE_SIGNER is set, the key is held by the signer process on the unix socket.
+	if path := os.Getenv("ORACLE_SIGNER"); path != "" {
+		signer, serr := oracle.DialSigner(path)
+		if serr != nil {
+			return nil, serr
+		}
+		d.olivia = oracle.NewOracleWithSigner("Olivia", params, d.rpc, signer)
+	} else {
+		d.olivia, err = oracle.NewOracle("Olivia", params, d.rpc)
+		if err != nil {
+			return nil, err
+		}
 	}
 	// Alice (User)
-	d.alice, err = usr.NewUser("Alice", params, d.rpc)
+	d.alice, err = newUser("Alice", d.rpc)
 	if err != nil {
 		return nil, err
 	}
 	// Bob (User)
-	d.bob, err = usr.NewUser("Bob", params, d.rpc)
+	d.bob, err = newUser("Bob", d.rpc)
 	if err != nil {
 		return nil, err
 	}
@@ -91,6 +101,22 @@ func initial() (*Demo, error) {
 	return d, nil
 }
 
+// newUser returns the user of regtest.
+// If DLC_STORE is set, the contracts are saved in the directory of the user name under it.
+func newUser(name string, r *rpc.BtcRPC) (*usr.User, error) {
+	u, err := usr.NewUser(name, chaincfg.RegressionNetParams, r)
+	if err != nil {
+		return nil, err
+	}
+	if dir := os.Getenv("DLC_STORE"); dir != "" {
+		err = u.SetStoreDir(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
 func console(demo *Demo) {
 	cmds := listCmds()
 	fmt.Print("$ ")
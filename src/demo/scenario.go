@@ -9,14 +9,17 @@ import (
 	"github.com/btcsuite/btcutil"
 
 	"dlc"
+	"usr"
 )
 
 type scenario struct {
 	memo   string
 	dlc    *dlc.Dlc
+	prev   *dlc.Dlc // contract before rollover
 	steps  []func(int, *Demo) error
 	pos    int
 	sendAB bool
+	pairs  [][2]*usr.User // users of Alice and Bob of contracts funded in batch
 }
 
 func (s *scenario) step(d *Demo) error {
@@ -51,6 +54,15 @@ func set(args []string, d *Demo) error {
 	list = append(list, scenario0)
 	list = append(list, scenario1)
 	list = append(list, scenario2)
+	list = append(list, scenario3)
+	list = append(list, scenario4)
+	list = append(list, scenario5)
+	list = append(list, scenario6)
+	list = append(list, scenario7)
+	list = append(list, scenario8)
+	list = append(list, scenario9)
+	list = append(list, scenario10)
+	list = append(list, scenario11)
 	if idx < 0 || len(list) <= idx {
 		return fmt.Errorf("out of range. %d,%d", idx, len(list))
 	}
@@ -140,15 +152,220 @@ func scenario2(d *Demo) (*scenario, error) {
 	return sc, nil
 }
 
+func scenario3(d *Demo) (*scenario, error) {
+	sc := &scenario{}
+	sc.memo = "Oracle cancels the event, and each collateral is returned without waiting for refund."
+	sc.sendAB = true
+	res, err := d.rpc.Request("getblockcount")
+	if err != nil {
+		return nil, err
+	}
+	height, _ := res.Result.(float64)
+	sc.dlc, err = makeDlc(true, int(height+10), 1)
+	if err != nil {
+		return nil, err
+	}
+	sc.steps = append(sc.steps, stepAliceSendOfferToBob)
+	sc.steps = append(sc.steps, stepBobSendAcceptToAlice)
+	sc.steps = append(sc.steps, stepAliceSendSignToBob)
+	sc.steps = append(sc.steps, stepAliceAndBobSetOracleCancel)
+	sc.steps = append(sc.steps, stepAliceOrBobSendSettlementTx)
+	return sc, nil
+}
+
+func scenario4(d *Demo) (*scenario, error) {
+	sc := &scenario{}
+	sc.memo = "Alice bet high 0.3 BTC against Bob's 0.7 BTC and pays all fees."
+	sc.sendAB = true
+	res, err := d.rpc.Request("getblockcount")
+	if err != nil {
+		return nil, err
+	}
+	height, _ := res.Result.(float64)
+	famta := int64(0.3 * btcutil.SatoshiPerBitcoin)
+	famtb := int64(0.7 * btcutil.SatoshiPerBitcoin)
+	sc.dlc, err = makeDlcWith(true, int(height+10), 1, famta, famtb)
+	if err != nil {
+		return nil, err
+	}
+	payers := &dlc.FeePayers{}
+	payers.Fund = dlc.PayerOfferer
+	payers.Settlement = dlc.PayerOfferer
+	payers.Refund = dlc.PayerOfferer
+	err = sc.dlc.SetFeePayers(payers, true)
+	if err != nil {
+		return nil, err
+	}
+	sc.steps = append(sc.steps, stepAliceSendOfferToBob)
+	sc.steps = append(sc.steps, stepBobSendAcceptToAlice)
+	sc.steps = append(sc.steps, stepAliceSendSignToBob)
+	sc.steps = append(sc.steps, stepAliceAndBobSetOracleSign)
+	sc.steps = append(sc.steps, stepAliceOrBobSendSettlementTx)
+	return sc, nil
+}
+
+func scenario5(d *Demo) (*scenario, error) {
+	sc := &scenario{}
+	sc.memo = "Alice and Bob agree to close early, and Alice gets 0.6 BTC without the oracle."
+	sc.sendAB = true
+	res, err := d.rpc.Request("getblockcount")
+	if err != nil {
+		return nil, err
+	}
+	height, _ := res.Result.(float64)
+	sc.dlc, err = makeDlc(true, int(height+10), 1)
+	if err != nil {
+		return nil, err
+	}
+	sc.steps = append(sc.steps, stepAliceSendOfferToBob)
+	sc.steps = append(sc.steps, stepBobSendAcceptToAlice)
+	sc.steps = append(sc.steps, stepAliceSendSignToBob)
+	sc.steps = append(sc.steps, stepAliceAndBobCloseTx)
+	return sc, nil
+}
+
+func scenario6(d *Demo) (*scenario, error) {
+	sc := &scenario{}
+	sc.memo = "Alice bet high in taproot mode and it ends normally."
+	sc.sendAB = true
+	res, err := d.rpc.Request("getblockcount")
+	if err != nil {
+		return nil, err
+	}
+	height, _ := res.Result.(float64)
+	sc.dlc, err = makeTaprootDlc(true, int(height+10), 1)
+	if err != nil {
+		return nil, err
+	}
+	sc.steps = append(sc.steps, stepAliceSendOfferToBob)
+	sc.steps = append(sc.steps, stepBobSendAcceptToAlice)
+	sc.steps = append(sc.steps, stepAliceSendSignToBob)
+	sc.steps = append(sc.steps, stepAliceAndBobSetOracleSign)
+	sc.steps = append(sc.steps, stepAliceOrBobSendSettlementTx)
+	return sc, nil
+}
+
+func scenario7(d *Demo) (*scenario, error) {
+	sc := &scenario{}
+	sc.memo = "Alice and Bob close early in taproot mode with MuSig2, and Alice gets 0.6 BTC."
+	sc.sendAB = true
+	res, err := d.rpc.Request("getblockcount")
+	if err != nil {
+		return nil, err
+	}
+	height, _ := res.Result.(float64)
+	sc.dlc, err = makeTaprootDlc(true, int(height+10), 1)
+	if err != nil {
+		return nil, err
+	}
+	sc.steps = append(sc.steps, stepAliceSendOfferToBob)
+	sc.steps = append(sc.steps, stepBobSendAcceptToAlice)
+	sc.steps = append(sc.steps, stepAliceSendSignToBob)
+	sc.steps = append(sc.steps, stepAliceAndBobCloseTx)
+	return sc, nil
+}
+
+func scenario8(d *Demo) (*scenario, error) {
+	sc := &scenario{}
+	sc.memo = "Since there is no Oracle, send a refund transaction in taproot mode."
+	sc.sendAB = true
+	res, err := d.rpc.Request("getblockcount")
+	if err != nil {
+		return nil, err
+	}
+	height, _ := res.Result.(float64)
+	sc.dlc, err = makeTaprootDlc(true, int(height+10), 1)
+	if err != nil {
+		return nil, err
+	}
+	sc.steps = append(sc.steps, stepAliceSendOfferToBob)
+	sc.steps = append(sc.steps, stepBobSendAcceptToAlice)
+	sc.steps = append(sc.steps, stepAliceSendSignToBob)
+	sc.steps = append(sc.steps, stepAliceOrBobSendRefundTx)
+	return sc, nil
+}
+
+func scenario9(d *Demo) (*scenario, error) {
+	sc := &scenario{}
+	sc.memo = "Alice and Bob roll over into the next contract of 0.3 BTC and 0.8 BTC, and it ends normally."
+	sc.sendAB = true
+	res, err := d.rpc.Request("getblockcount")
+	if err != nil {
+		return nil, err
+	}
+	height, _ := res.Result.(float64)
+	sc.dlc, err = makeDlc(true, int(height+10), 1)
+	if err != nil {
+		return nil, err
+	}
+	sc.steps = append(sc.steps, stepAliceSendOfferToBob)
+	sc.steps = append(sc.steps, stepBobSendAcceptToAlice)
+	sc.steps = append(sc.steps, stepAliceSendSignToBob)
+	sc.steps = append(sc.steps, stepAliceSendRolloverOfferToBob)
+	sc.steps = append(sc.steps, stepBobSendAcceptToAlice)
+	sc.steps = append(sc.steps, stepAliceSendSignToBob)
+	sc.steps = append(sc.steps, stepAliceAndBobConfirmRollover)
+	sc.steps = append(sc.steps, stepAliceAndBobSetOracleSign)
+	sc.steps = append(sc.steps, stepAliceOrBobSendSettlementTx)
+	return sc, nil
+}
+
+func scenario10(d *Demo) (*scenario, error) {
+	sc := &scenario{}
+	sc.memo = "Alice offers two contracts to Bob funded by one transaction, and they end normally."
+	sc.sendAB = true
+	sc.steps = append(sc.steps, stepAliceAndBobBatchFundTx)
+	sc.steps = append(sc.steps, stepAliceSendBatchSettlementTxs)
+	return sc, nil
+}
+
+func scenario11(d *Demo) (*scenario, error) {
+	sc := &scenario{}
+	sc.memo = "Bob does not send the rollover, and Alice refunds the previous contract."
+	sc.sendAB = true
+	res, err := d.rpc.Request("getblockcount")
+	if err != nil {
+		return nil, err
+	}
+	height, _ := res.Result.(float64)
+	sc.dlc, err = makeDlc(true, int(height+10), 1)
+	if err != nil {
+		return nil, err
+	}
+	sc.steps = append(sc.steps, stepAliceSendOfferToBob)
+	sc.steps = append(sc.steps, stepBobSendAcceptToAlice)
+	sc.steps = append(sc.steps, stepAliceSendSignToBob)
+	sc.steps = append(sc.steps, stepAliceSendRolloverOfferToBob)
+	sc.steps = append(sc.steps, stepBobSendAcceptToAlice)
+	sc.steps = append(sc.steps, stepAliceSendRolloverSignToBob)
+	sc.steps = append(sc.steps, stepAliceAbortRollover)
+	sc.steps = append(sc.steps, stepAliceOrBobSendRefundTx)
+	return sc, nil
+}
+
 //----------------------------------------------------------------
 
+func makeTaprootDlc(high bool, count int, length int) (*dlc.Dlc, error) {
+	d, err := makeDlc(high, count, length)
+	if err != nil {
+		return nil, err
+	}
+	err = d.SetTaproot(true)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
 func makeDlc(high bool, count int, length int) (*dlc.Dlc, error) {
 	amount := int64(1 * btcutil.SatoshiPerBitcoin)
-	fefee := int64(10)                      // fund transaction estimate fee satoshi/byte
-	sefee := int64(10)                      // settlement transaction estimate fee satoshi/byte
-	sfee := dlc.DlcSettlementTxSize * sefee // settlement transaction size is 345 bytes
-	d, err := dlc.NewDlc(half(amount), half(amount), fefee,
-		sefee, half(sfee), half(sfee), high)
+	return makeDlcWith(high, count, length, half(amount), half(amount))
+}
+
+func makeDlcWith(high bool, count int, length int, famta, famtb int64) (*dlc.Dlc, error) {
+	fefee := int64(10) // fund transaction estimate fee satoshi/byte
+	sefee := int64(10) // settlement transaction estimate fee satoshi/byte
+	d, err := dlc.NewDlc(famta, famtb, fefee, sefee, high)
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+
 	"usr"
 )
 
@@ -87,6 +90,101 @@ func stepAliceSendSignToBob(num int, d *Demo) error {
 	return nil
 }
 
+func stepAliceSendRolloverOfferToBob(num int, d *Demo) error {
+	s := time.Now()
+	fmt.Printf("begin step%d\n", num)
+	res, err := d.rpc.Request("getblockcount")
+	if err != nil {
+		return err
+	}
+	height, _ := res.Result.(float64)
+	famta := int64(0.3 * btcutil.SatoshiPerBitcoin)
+	famtb := int64(0.8 * btcutil.SatoshiPerBitcoin)
+	next, err := makeDlcWith(true, int(height+10), 1, famta, famtb)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("step%d : Alice GetRolloverOfferData\n", num)
+	amount := d.sc.dlc.RolloverShare(true)
+	odata, err := d.alice.GetRolloverOfferData(next, amount)
+	if err != nil {
+		return err
+	}
+	d.sc.prev, d.sc.dlc = d.sc.dlc, next
+	fmt.Printf("step%d : Alice SetOracleKeys\n", num)
+	keys, err := d.olivia.Keys(d.alice.GameHeight())
+	if err != nil {
+		return err
+	}
+	err = d.alice.SetOracleKeys(keys)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("step%d : Alice -> Bob\n", num)
+	dump(odata)
+	fmt.Printf("step%d : Bob SetRolloverOfferData\n", num)
+	err = d.bob.SetRolloverOfferData(odata)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("end   step%d %f sec\n", num, (time.Now()).Sub(s).Seconds())
+	return nil
+}
+
+func stepAliceSendRolloverSignToBob(num int, d *Demo) error {
+	s := time.Now()
+	fmt.Printf("begin step%d\n", num)
+	fmt.Printf("step%d : Alice GetSignData\n", num)
+	sdata, err := d.alice.GetSignData()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("step%d : Alice -> Bob\n", num)
+	dump(sdata)
+	fmt.Printf("step%d : Bob SetSignData\n", num)
+	err = d.bob.SetSignData(sdata)
+	if err != nil {
+		return err
+	}
+	// Bob does not send the fund transaction of rollover.
+	fmt.Printf("end   step%d %f sec\n", num, (time.Now()).Sub(s).Seconds())
+	return nil
+}
+
+func stepAliceAndBobConfirmRollover(num int, d *Demo) error {
+	s := time.Now()
+	fmt.Printf("begin step%d\n", num)
+	_, err := d.rpc.Request("generate", 1)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("step%d : Alice ConfirmRollover\n", num)
+	err = d.alice.ConfirmRollover()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("step%d : Bob ConfirmRollover\n", num)
+	err = d.bob.ConfirmRollover()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("end   step%d %f sec\n", num, (time.Now()).Sub(s).Seconds())
+	return nil
+}
+
+func stepAliceAbortRollover(num int, d *Demo) error {
+	s := time.Now()
+	fmt.Printf("begin step%d\n", num)
+	fmt.Printf("step%d : Alice AbortRollover\n", num)
+	err := d.alice.AbortRollover()
+	if err != nil {
+		return err
+	}
+	d.sc.dlc = d.sc.prev
+	fmt.Printf("end   step%d %f sec\n", num, (time.Now()).Sub(s).Seconds())
+	return nil
+}
+
 func stepAliceAndBobSetOracleSign(num int, d *Demo) error {
 	s := time.Now()
 	fmt.Printf("begin step%d\n", num)
@@ -113,6 +211,32 @@ func stepAliceAndBobSetOracleSign(num int, d *Demo) error {
 	return nil
 }
 
+func stepAliceAndBobSetOracleCancel(num int, d *Demo) error {
+	s := time.Now()
+	fmt.Printf("begin step%d\n", num)
+	height := d.alice.GameHeight()
+	sigs, err := d.olivia.Cancel(height)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("step%d : Alice & Bob SetOracleSigns (cancel)\n", num)
+	err = d.alice.SetOracleSigns(sigs)
+	if err != nil {
+		return err
+	}
+	height = d.bob.GameHeight()
+	sigs, err = d.olivia.Cancel(height)
+	if err != nil {
+		return err
+	}
+	err = d.bob.SetOracleSigns(sigs)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("end   step%d %f sec\n", num, (time.Now()).Sub(s).Seconds())
+	return nil
+}
+
 func stepAliceOrBobSendSettlementTx(num int, demo *Demo) error {
 	s := time.Now()
 	fmt.Printf("begin step%d\n", num)
@@ -126,16 +250,48 @@ func stepAliceOrBobSendSettlementTx(num int, demo *Demo) error {
 			fmt.Printf("SendSettlementTx error : %+v\n", err)
 			continue
 		}
-		err = user.SendSettlementTxTo(int64(10))
-		if err != nil {
-			return err
-		}
 		break
 	}
 	fmt.Printf("end   step%d %f sec\n", num, (time.Now()).Sub(s).Seconds())
 	return nil
 }
 
+func stepAliceAndBobCloseTx(num int, d *Demo) error {
+	s := time.Now()
+	fmt.Printf("begin step%d\n", num)
+	fmt.Printf("step%d : Alice GetCloseOfferData\n", num)
+	amount := int64(0.6 * btcutil.SatoshiPerBitcoin)
+	cdata, err := d.alice.GetCloseOfferData(amount, int64(10))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("step%d : Alice -> Bob\n", num)
+	dump(cdata)
+	fmt.Printf("step%d : Bob SetCloseOfferData\n", num)
+	err = d.bob.SetCloseOfferData(cdata)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("step%d : Bob GetCloseAcceptData\n", num)
+	adata, err := d.bob.GetCloseAcceptData()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("step%d : Bob -> Alice\n", num)
+	dump(adata)
+	fmt.Printf("step%d : Alice SetCloseAcceptData\n", num)
+	err = d.alice.SetCloseAcceptData(adata)
+	if err != nil {
+		return err
+	}
+	err = d.alice.SendCloseTx()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("end   step%d %f sec\n", num, (time.Now()).Sub(s).Seconds())
+	return nil
+}
+
 func stepAliceOrBobSendRefundTx(num int, demo *Demo) error {
 	s := time.Now()
 	fmt.Printf("begin step%d\n", num)
@@ -143,10 +299,144 @@ func stepAliceOrBobSendRefundTx(num int, demo *Demo) error {
 	if !demo.sc.sendAB {
 		user = demo.bob
 	}
-	err := user.SendRefundTx()
+	// The refund transaction is valid after the locktime.
+	res, err := demo.rpc.Request("getblockcount")
+	if err != nil {
+		return err
+	}
+	count, _ := res.Result.(float64)
+	locktime := demo.sc.dlc.RefundLocktime()
+	if locktime < txscript.LockTimeThreshold && int64(count) < int64(locktime) {
+		fmt.Printf("step%d : generate %d\n", num, int64(locktime)-int64(count))
+		_, err = demo.rpc.Request("generate", int64(locktime)-int64(count))
+		if err != nil {
+			return err
+		}
+	}
+	err = user.SendRefundTx()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("end   step%d %f sec\n", num, (time.Now()).Sub(s).Seconds())
+	return nil
+}
+
+func stepAliceAndBobBatchFundTx(num int, d *Demo) error {
+	s := time.Now()
+	fmt.Printf("begin step%d\n", num)
+	res, err := d.rpc.Request("getblockcount")
+	if err != nil {
+		return err
+	}
+	height, _ := res.Result.(float64)
+	// The users of each contract have the same wallet.
+	alices, bobs := []*usr.User{}, []*usr.User{}
+	for i := 0; i < 2; i++ {
+		alice, err := newUser(d.alice.Name(), d.rpc)
+		if err != nil {
+			return err
+		}
+		bob, err := newUser(d.bob.Name(), d.rpc)
+		if err != nil {
+			return err
+		}
+		alices, bobs = append(alices, alice), append(bobs, bob)
+		d.sc.pairs = append(d.sc.pairs, [2]*usr.User{alice, bob})
+	}
+	abatch, err := usr.NewBatch(alices)
+	if err != nil {
+		return err
+	}
+	_, err = usr.NewBatch(bobs)
 	if err != nil {
 		return err
 	}
+	for i, pair := range d.sc.pairs {
+		alice, bob := pair[0], pair[1]
+		dlc, err := makeDlc(i == 0, int(height+10), 1)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("step%d : Alice GetOfferData of contract %d\n", num, i)
+		odata, err := alice.GetOfferData(dlc)
+		if err != nil {
+			return err
+		}
+		keys, err := d.olivia.Keys(alice.GameHeight())
+		if err != nil {
+			return err
+		}
+		err = alice.SetOracleKeys(keys)
+		if err != nil {
+			return err
+		}
+		err = bob.SetOfferData(odata)
+		if err != nil {
+			return err
+		}
+		err = bob.SetOracleKeys(keys)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("step%d : Bob GetAcceptData of contract %d\n", num, i)
+		adata, err := bob.GetAcceptData()
+		if err != nil {
+			return err
+		}
+		err = alice.SetAcceptData(adata)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("step%d : Alice GetBatchData\n", num)
+	list, err := abatch.GetBatchData()
+	if err != nil {
+		return err
+	}
+	for i, pair := range d.sc.pairs {
+		alice, bob := pair[0], pair[1]
+		fmt.Printf("step%d : Bob SetBatchData and GetSignData of contract %d\n", num, i)
+		dump(list[i])
+		err = bob.SetBatchData(list[i])
+		if err != nil {
+			return err
+		}
+		sdata, err := bob.GetSignData()
+		if err != nil {
+			return err
+		}
+		err = alice.SetSignData(sdata)
+		if err != nil {
+			return err
+		}
+	}
+	err = abatch.SendFundTx()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("end   step%d %f sec\n", num, (time.Now()).Sub(s).Seconds())
+	return nil
+}
+
+func stepAliceSendBatchSettlementTxs(num int, d *Demo) error {
+	s := time.Now()
+	fmt.Printf("begin step%d\n", num)
+	for i, pair := range d.sc.pairs {
+		alice := pair[0]
+		sigs, err := d.olivia.Signs(alice.GameHeight())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("step%d : Alice SetOracleSigns and SendSettlementTx of contract %d\n", num, i)
+		err = alice.SetOracleSigns(sigs)
+		if err != nil {
+			return err
+		}
+		err = alice.SendSettlementTx()
+		if err != nil {
+			return err
+		}
+	}
 	fmt.Printf("end   step%d %f sec\n", num, (time.Now()).Sub(s).Seconds())
 	return nil
 }
@@ -0,0 +1,99 @@
+// Package dlc project batch.go
+package dlc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// SetBatch places the fund outputs of dlcs in a single fund transaction.
+// Each contract keeps the txins and txouts of the others as the batch,
+// and its fund output is at own vout of the fund transaction.
+func SetBatch(dlcs []*Dlc) error {
+	if len(dlcs) < 2 {
+		return fmt.Errorf("batch needs contracts : %d", len(dlcs))
+	}
+	for _, d := range dlcs {
+		if d.fundPkScript() == nil {
+			return fmt.Errorf("not found fund output")
+		}
+		if d.roll != nil {
+			return fmt.Errorf("rollover is not batched")
+		}
+	}
+	for i, d := range dlcs {
+		txins, txouts := []*FundTxIn{}, []*FundTxOut{}
+		for j, other := range dlcs {
+			if i == j {
+				continue
+			}
+			// The witnesses are not shared.
+			for _, txin := range other.ownTxIns() {
+				op := txin.TxIn.PreviousOutPoint
+				txins = append(txins, &FundTxIn{txin.Serial, wire.NewTxIn(&op, nil, nil)})
+			}
+			txouts = append(txouts, other.ownTxOuts()...)
+			txouts = append(txouts, other.fundTxOut())
+		}
+		err := d.SetBatchTxInsAndTxOuts(txins, txouts)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetBatchTxInsAndTxOuts sets txins and txouts of the other contracts in the fund transaction.
+func (d *Dlc) SetBatchTxInsAndTxOuts(txins []*FundTxIn, txouts []*FundTxOut) error {
+	oins, oouts := d.otxins, d.otxouts
+	d.otxins, d.otxouts = txins, txouts
+	err := d.checkSerials()
+	if err != nil {
+		d.otxins, d.otxouts = oins, oouts
+		return err
+	}
+	return nil
+}
+
+// BatchTxIns returns the txins of the other contracts in the fund transaction.
+func (d *Dlc) BatchTxIns() []*FundTxIn {
+	return d.otxins
+}
+
+// BatchTxOuts returns the txouts of the other contracts in the fund transaction.
+func (d *Dlc) BatchTxOuts() []*FundTxOut {
+	return d.otxouts
+}
+
+// IsBatch returns true if the fund transaction has the other contracts.
+func (d *Dlc) IsBatch() bool {
+	return len(d.otxins) > 0 || len(d.otxouts) > 0
+}
+
+// ownTxIns returns the txins of A and B, and the txin spending previous fund output in rollover.
+func (d *Dlc) ownTxIns() []*FundTxIn {
+	txins := append(append([]*FundTxIn{}, d.atxins...), d.btxins...)
+	if d.roll != nil {
+		txin, err := d.roll.txIn()
+		if err == nil {
+			txins = append(txins, txin)
+		}
+	}
+	return txins
+}
+
+// ownTxOuts returns the change txouts of A and B.
+func (d *Dlc) ownTxOuts() []*FundTxOut {
+	return append(append([]*FundTxOut{}, d.atxouts...), d.btxouts...)
+}
+
+// fundTxOut returns the fund output with serial id, or nil if the public keys are not set.
+func (d *Dlc) fundTxOut() *FundTxOut {
+	pkScript := d.fundPkScript()
+	if pkScript == nil {
+		return nil
+	}
+	txout := wire.NewTxOut(d.famta+d.famtb+d.sfeea+d.sfeeb, pkScript)
+	return &FundTxOut{d.fserial, txout}
+}
@@ -0,0 +1,103 @@
+// Package dlc project close.go
+package dlc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/wire"
+
+	"schnorr"
+)
+
+// CloseTx returns the cooperative close transaction paying amta to A and amtb to B.
+// The rest of fund output is the fee.
+func (d *Dlc) CloseTx(amta, amtb int64) *wire.MsgTx {
+	// close transaction
+	// input:
+	//   [0]:fund transaction output[fund vout]
+	// output:
+	//   [0]:payout pkScript a (not dust)
+	//   [1]:payout pkScript b (not dust)
+	tx := wire.NewMsgTx(2)
+	txid := d.FundTx().TxHash()
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&txid, d.FundVout()), nil, nil))
+	// The dust outputs are folded into fee.
+	if pkScript := d.PayoutScript(true); !IsDust(amta, pkScript) {
+		tx.AddTxOut(wire.NewTxOut(amta, pkScript))
+	}
+	if pkScript := d.PayoutScript(false); !IsDust(amtb, pkScript) {
+		tx.AddTxOut(wire.NewTxOut(amtb, pkScript))
+	}
+	return tx
+}
+
+// CloseTxWeight returns the weight of close transaction with both outputs.
+func (d *Dlc) CloseTxWeight() int64 {
+	return TxWeight(1, 2) + d.closeInputWeight() +
+		OutputWeight(d.PayoutScript(true)) + OutputWeight(d.PayoutScript(false))
+}
+
+// CloseAmounts returns the amounts of A and B for close transaction,
+// where amount is paid to A or B and the rest is paid to the other after the fee at efee.
+func (d *Dlc) CloseAmounts(amount int64, isA bool, efee int64) (int64, int64, error) {
+	fee := WeightToFee(d.CloseTxWeight(), efee)
+	rest := d.FundAmount() + d.SettlementFee() - fee - amount
+	if amount < 0 || rest < 0 {
+		return 0, 0, fmt.Errorf("illegal close amount : %d, %d, %d", amount, rest, fee)
+	}
+	if isA {
+		return amount, rest, nil
+	}
+	return rest, amount, nil
+}
+
+// CheckCloseAmounts checks the amounts of A and B for close transaction are in fund output.
+func (d *Dlc) CheckCloseAmounts(amta, amtb int64) error {
+	if amta < 0 || amtb < 0 || amta+amtb > d.FundAmount()+d.SettlementFee() {
+		return fmt.Errorf("illegal close amounts : %d, %d", amta, amtb)
+	}
+	return nil
+}
+
+// VerifyCloseTx verifies the signature of close transaction.
+func (d *Dlc) VerifyCloseTx(amta, amtb int64, sign []byte, pub *btcec.PublicKey) error {
+	err := d.CheckCloseAmounts(amta, amtb)
+	if err != nil {
+		return err
+	}
+	return d.verifyFundSign(d.CloseTx(amta, amtb), sign, pub)
+}
+
+// CloseWitness returns the witness of close transaction from the signatures of A and B.
+func (d *Dlc) CloseWitness(signa, signb []byte) wire.TxWitness {
+	return wire.TxWitness{[]byte{}, signa, signb, d.FundScript()}
+}
+
+// verifyFundSign verifies the signature of tx spending fund output.
+// In taproot mode, it is the signature for the fund leaf.
+func (d *Dlc) verifyFundSign(tx *wire.MsgTx, sign []byte, pub *btcec.PublicKey) error {
+	hash, err := d.FundSigHash(tx, false)
+	if err != nil {
+		return err
+	}
+	return d.verifySignHash(hash, sign, pub)
+}
+
+// verifySignHash verifies the signature of hash spending fund output.
+func (d *Dlc) verifySignHash(hash, sign []byte, pub *btcec.PublicKey) error {
+	if d.taproot {
+		return schnorr.Verify(pub, hash, sign)
+	}
+	// parse signature
+	s, err := btcec.ParseDERSignature(sign, btcec.S256())
+	if err != nil {
+		return err
+	}
+	// verify
+	verify := s.Verify(hash, pub)
+	if !verify {
+		return fmt.Errorf("verify fail : %v", verify)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Package dlc project descriptor.go
+package dlc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// DescriptorVersion is the version of contract descriptor.
+// Version 3 drops the settlement delay from the timelocks,
+// so the older descriptors are rejected.
+const DescriptorVersion = 3
+
+// MaxGameLength is the maximum length of target message.
+// All outcomes are enumerated to make rates.
+const MaxGameLength = 3
+
+// Descriptor is the contract descriptor.
+type Descriptor struct {
+	Version   int                `json:"version"`   // version of descriptor
+	Payout    *PayoutDescriptor  `json:"payout"`    // payout function
+	Event     *EventDescriptor   `json:"event"`     // oracle event
+	Rounding  []RoundingInterval `json:"rounding"`  // rounding intervals of payout
+	Timelocks *Timelocks         `json:"timelocks"` // timelocks of transactions
+	Taproot   bool               `json:"taproot"`   // taproot mode
+}
+
+// EventDescriptor is the descriptor of oracle event.
+type EventDescriptor struct {
+	Height int `json:"height"` // height of target block
+	Length int `json:"length"` // length of target message
+	Base   int `json:"base"`   // base of digit
+}
+
+// Timelocks is the timelocks of transactions.
+type Timelocks struct {
+	Refund uint32 `json:"refund"` // locktime of refund transaction
+}
+
+// Descriptor returns the contract descriptor.
+func (d *Dlc) Descriptor() *Descriptor {
+	desc := &Descriptor{}
+	desc.Version = DescriptorVersion
+	desc.Payout = d.PayoutFunction().Descriptor()
+	desc.Event = &EventDescriptor{d.height, d.length, DigitBase}
+	desc.Rounding = d.rounding
+	desc.Timelocks = &Timelocks{d.locktime}
+	desc.Taproot = d.taproot
+	return desc
+}
+
+// SetDescriptor sets the contract by descriptor.
+func (d *Dlc) SetDescriptor(desc *Descriptor) error {
+	if desc == nil {
+		return fmt.Errorf("contract descriptor is nil")
+	}
+	if desc.Version != DescriptorVersion {
+		return fmt.Errorf("unknown descriptor version : %d", desc.Version)
+	}
+	if desc.Event == nil || desc.Timelocks == nil {
+		return fmt.Errorf("illegal contract descriptor : %+v", desc)
+	}
+	ev := desc.Event
+	if ev.Base != DigitBase {
+		return fmt.Errorf("unsupported digit base : %d", ev.Base)
+	}
+	if ev.Height < 0 || ev.Length < 1 || ev.Length > MaxGameLength || ev.Length > chainhash.HashSize {
+		return fmt.Errorf("illegal event : %+v", ev)
+	}
+	payout, err := NewPayoutFunction(desc.Payout)
+	if err != nil {
+		return err
+	}
+	err = checkRoundingIntervals(desc.Rounding)
+	if err != nil {
+		return err
+	}
+	err = d.SetTimelocks(desc.Timelocks.Refund)
+	if err != nil {
+		return err
+	}
+	d.height = ev.Height
+	d.length = ev.Length
+	d.payout = payout
+	d.rounding = desc.Rounding
+	d.resetRates()
+	return d.SetTaproot(desc.Taproot)
+}
@@ -10,19 +10,10 @@ import (
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
-)
-
-// DlcSettlementTxSize is the size(byte) for settlement transaction.
-const DlcSettlementTxSize = int64(345)
-
-// DlcFundTxBaseSize is the size(byte) for fund transaction including 1 output.
-const DlcFundTxBaseSize = int64(55)
 
-// DlcTxInSize is the size(byte) per txin.
-const DlcTxInSize = int64(149)
-
-// DlcTxOutSize is the size(byte) per txout.
-const DlcTxOutSize = int64(31)
+	"ecdsa"
+	"schnorr"
+)
 
 // Dlc is the dlc dataset.
 type Dlc struct {
@@ -32,23 +23,38 @@ type Dlc struct {
 	sefee    int64            // Settlement estimate fee (satotshi/byte)
 	sfeea    int64            // Settlement fee a (satoshi)
 	sfeeb    int64            // Settlement fee b (satoshi)
+	ffeea    int64            // Fund base fee a (satoshi)
+	ffeeb    int64            // Fund base fee b (satoshi)
+	payers   *FeePayers       // Fee payers
+	offerA   bool             // Is the offerer a?
 	isA      bool             // Is this contract a's?
+	tempID   []byte           // Temporary contract id
 	locktime uint32           // Refund transaction locktime
 	puba     *btcec.PublicKey // Public key a
 	pubb     *btcec.PublicKey // Public key b
-	atxins   []*wire.TxIn     // Fund outpoints a
-	btxins   []*wire.TxIn     // Fund outpoints b
-	txouta   *wire.TxOut      // Fund txout a
-	txoutb   *wire.TxOut      // Fund txout b
+	atxins   []*FundTxIn      // Fund txins a
+	btxins   []*FundTxIn      // Fund txins b
+	atxouts  []*FundTxOut     // Fund change txouts a
+	btxouts  []*FundTxOut     // Fund change txouts b
+	fserial  uint64           // Fund output serial id
+	pscripta []byte           // Payout pkScript a
+	pscriptb []byte           // Payout pkScript b
 	rsigna   []byte           // Refund signature a
 	rsignb   []byte           // Refund signature b
+	taproot  bool             // Is the fund output taproot?
+	roll     *Rollover        // Previous fund output in rollover
+	otxins   []*FundTxIn      // Fund txins of the other contracts in batch
+	otxouts  []*FundTxOut     // Fund txouts of the other contracts in batch
 	// Parameters with different formats by Oracle
-	pubo   *btcec.PublicKey   // Oracle public key
-	okeys  []*btcec.PublicKey // Oracle contract keys
-	omsgs  [][]byte           // Oracle contract Fixed messages
-	osigns []*big.Int         // Oracle contract Fixed signs
-	rates  []*Rate            // Rate list
-	frate  *Rate              // Fixed rate
+	pubo     *btcec.PublicKey   // Oracle public key
+	okeys    []*btcec.PublicKey // Oracle contract keys
+	omsgs    [][]byte           // Oracle contract Fixed messages
+	osigns   []*big.Int         // Oracle contract Fixed signs
+	rates    []*Rate            // Rate list
+	trie     *rateTrie          // Trie of rates
+	payout   PayoutFunction     // Payout function
+	rounding []RoundingInterval // Rounding intervals of payout
+	frate    *Rate              // Fixed rate
 	// Game original parameters
 	height int             // Block height
 	length int             // Target length
@@ -61,9 +67,9 @@ type Rate struct {
 	amta  int64            // Settlement amount a
 	amtb  int64            // Settlement amount b
 	key   *btcec.PublicKey // Settlement messages public key
-	rsign []byte           // Signature of settlement transaction received
+	rsign []byte           // Adaptor signature of settlement transaction received
 	msign *big.Int         // Fixed messages sign
-	txid  *chainhash.Hash  // Settlement txid signed by itself
+	txid  *chainhash.Hash  // Settlement txid
 }
 
 // NewRate returns a new Rate.
@@ -98,7 +104,7 @@ func (r *Rate) Amount(isA bool) int64 {
 	return r.amtb
 }
 
-// ReceivedSign returns signature of settlement transaction received.
+// ReceivedSign returns adaptor signature of settlement transaction received.
 func (r *Rate) ReceivedSign() []byte {
 	return r.rsign
 }
@@ -108,16 +114,25 @@ func (r *Rate) MessageSign() *big.Int {
 	return r.msign
 }
 
+// Key returns public key of messages, which is the point of the messages sign.
+func (r *Rate) Key() *btcec.PublicKey {
+	return r.key
+}
+
 // NewDlc returns a new Dlc.
-func NewDlc(famta, famtb, fefee, sefee, sfeea, sfeeb int64, isA bool) (*Dlc, error) {
+// The fees are paid by both A and B until SetFeePayers.
+func NewDlc(famta, famtb, fefee, sefee int64, isA bool) (*Dlc, error) {
 	d := &Dlc{}
 	d.famta = famta // Fund amount a (satoshi)
 	d.famtb = famtb // Fund amount b (satoshi)
 	d.fefee = fefee // Fund estimate fee (satotshi/byte)
 	d.sefee = sefee // Settlement estimate fee (satotshi/byte)
-	d.sfeea = sfeea // Settlement fee a (satoshi)
-	d.sfeeb = sfeeb // Settlement fee b (satoshi)
 	d.isA = isA     // Is this contract a's?
+	payers := &FeePayers{PayerBoth, PayerBoth, PayerBoth}
+	err := d.SetFeePayers(payers, isA)
+	if err != nil {
+		return nil, err
+	}
 	return d, nil
 }
 
@@ -130,23 +145,31 @@ func (d *Dlc) SetPublicKey(pub *btcec.PublicKey, isA bool) {
 	}
 }
 
-// SetTxInsAndTxOut sets txins and txout of A or B.
-func (d *Dlc) SetTxInsAndTxOut(txins []*wire.TxIn, txout *wire.TxOut, isA bool) {
+// SetPayoutScript sets the payout pkScript of A or B.
+func (d *Dlc) SetPayoutScript(pkScript []byte, isA bool) error {
+	err := CheckPayoutScript(pkScript)
+	if err != nil {
+		return err
+	}
 	if isA {
-		d.atxins = txins
-		d.txouta = txout
+		d.pscripta = pkScript
 	} else {
-		d.btxins = txins
-		d.txoutb = txout
+		d.pscriptb = pkScript
 	}
+	return nil
 }
 
-// FundTxIns returns the txins of A or B for fund transaction.
-func (d *Dlc) FundTxIns(isA bool) []*wire.TxIn {
+// PayoutScript returns the payout pkScript of A or B.
+// If it is not set, P2WPKH of the public key is returned.
+func (d *Dlc) PayoutScript(isA bool) []byte {
+	pkScript := d.pscriptb
 	if isA {
-		return d.atxins
+		pkScript = d.pscripta
+	}
+	if pkScript == nil {
+		return P2WPKHpkScript(d.PublicKey(isA))
 	}
-	return d.btxins
+	return pkScript
 }
 
 // SetRefundSign sets signs of A or B for refund transaction.
@@ -168,6 +191,35 @@ func (d *Dlc) FundAmount() int64 {
 	return d.famta + d.famtb
 }
 
+// Collateral returns the fund amount of A or B.
+func (d *Dlc) Collateral(isA bool) int64 {
+	if isA {
+		return d.famta
+	}
+	return d.famtb
+}
+
+// FundTxAmount returns the amount A or B pays into fund transaction,
+// which is the collateral, the share of settlement fee and the share of fund base fee.
+// In rollover, the amount from previous fund output is subtracted,
+// and the negative amount is returned to A or B.
+func (d *Dlc) FundTxAmount(isA bool) int64 {
+	if isA {
+		return d.famta + d.sfeea + d.ffeea - d.rolloverAmount(true)
+	}
+	return d.famtb + d.sfeeb + d.ffeeb - d.rolloverAmount(false)
+}
+
+// RefundAmount returns the amount of refund transaction to A or B.
+func (d *Dlc) RefundAmount(isA bool) int64 {
+	rfee := WeightToFee(d.RefundTxWeight(), d.sefee)
+	rfeea, rfeeb, _ := splitFee(rfee, d.payers.Refund, d.offerA)
+	if isA {
+		return d.famta + d.sfeea - rfeea
+	}
+	return d.famtb + d.sfeeb - rfeeb
+}
+
 // SettlementFee returns the total fee for settlement transaction.
 func (d *Dlc) SettlementFee() int64 {
 	return d.sfeea + d.sfeeb
@@ -183,6 +235,11 @@ func (d *Dlc) SettlementEstimateFee() int64 {
 	return d.sefee
 }
 
+// RefundLocktime returns the locktime of refund transaction.
+func (d *Dlc) RefundLocktime() uint32 {
+	return d.locktime
+}
+
 // PublicKey returns the public key of A or B.
 func (d *Dlc) PublicKey(isA bool) *btcec.PublicKey {
 	if isA {
@@ -196,6 +253,11 @@ func (d *Dlc) FundScript() []byte {
 	if d.puba == nil || d.pubb == nil {
 		return nil
 	}
+	return fundScript(d.puba, d.pubb)
+}
+
+// fundScript returns a funds script of public keys a and b.
+func fundScript(puba, pubb *btcec.PublicKey) []byte {
 	// fund script:
 	// OP_2
 	//   <public key a>
@@ -204,245 +266,201 @@ func (d *Dlc) FundScript() []byte {
 	// OP_CHECKMULTISIG
 	builder := txscript.NewScriptBuilder()
 	builder.AddOp(txscript.OP_2)
-	builder.AddData(d.puba.SerializeCompressed())
-	builder.AddData(d.pubb.SerializeCompressed())
+	builder.AddData(puba.SerializeCompressed())
+	builder.AddData(pubb.SerializeCompressed())
 	builder.AddOp(txscript.OP_2)
 	builder.AddOp(txscript.OP_CHECKMULTISIG)
 	script, _ := builder.Script()
 	return script
 }
 
-// SettlementScript returns settlement script.
-func SettlementScript(pub1, pub2 *btcec.PublicKey) []byte {
-	// settlement script:
-	// OP_IF
-	//   <public key a/b add message keys>
-	// OP_ELSE
-	//   delay(fix 144?)
-	//   OP_CHECKSEQUENCEVERIFY
-	//   OP_DROP
-	//   <public key b/a>
-	// OP_ENDIF
-	// OP_CHECKSIG
-	delay := uint16(144)
-	csvflg := uint32(0x00000000)
-	builder := txscript.NewScriptBuilder()
-	builder.AddOp(txscript.OP_IF)
-	builder.AddData(pub1.SerializeCompressed())
-	builder.AddOp(txscript.OP_ELSE)
-	builder.AddInt64(int64(delay) + int64(csvflg))
-	builder.AddOp(txscript.OP_CHECKSEQUENCEVERIFY)
-	builder.AddOp(txscript.OP_DROP)
-	builder.AddData(pub2.SerializeCompressed())
-	builder.AddOp(txscript.OP_ENDIF)
-	builder.AddOp(txscript.OP_CHECKSIG)
-	script, _ := builder.Script()
-	return script
-}
-
 // FundTx returns fund transaction.
 func (d *Dlc) FundTx() *wire.MsgTx {
 	// fund transaction
 	// input:
-	//   [*]:inputs of a
-	//   [*]:inputs of b
+	//   [*]:inputs of a, b and the other contracts in batch sorted by serial id
 	// output:
-	//   [0] fund script (2-of-2 multisig)
-	//   [*] output of a (option)
-	//   [*] output of b (option)
+	//   [*]:fund script (2-of-2 multisig or taproot) and outputs of a, b
+	//       and the other contracts in batch sorted by serial id
 	tx := wire.NewMsgTx(2)
-	for _, txin := range d.atxins {
-		tx.AddTxIn(txin)
-	}
-	for _, txin := range d.btxins {
-		tx.AddTxIn(txin)
+	for _, txin := range sortTxIns(append(d.ownTxIns(), d.otxins...)) {
+		tx.AddTxIn(txin.TxIn)
 	}
-	script := d.FundScript()
-	if script != nil {
-		txout := wire.NewTxOut(d.famta+d.famtb+d.sfeea+d.sfeeb, P2WSHpkScript(script))
-		tx.AddTxOut(txout)
-		if d.txouta != nil {
-			tx.AddTxOut(d.txouta)
-		}
-		if d.txoutb != nil {
-			tx.AddTxOut(d.txoutb)
+	fund := d.fundTxOut()
+	if fund != nil {
+		txouts := append(append(d.ownTxOuts(), d.otxouts...), fund)
+		for _, txout := range sortTxOuts(txouts) {
+			tx.AddTxOut(txout.TxOut)
 		}
 	}
 	return tx
 }
 
-// SettlementTx returns a settlement transaction by rate and A or B.
-func (d *Dlc) SettlementTx(rate *Rate, isA bool) *wire.MsgTx {
+// SettlementTx returns the settlement transaction of rate, which is shared by A and B.
+func (d *Dlc) SettlementTx(rate *Rate) *wire.MsgTx {
 	// settlement transaction
 	// input:
-	//   [0]:fund transaction output[0]
+	//   [0]:fund transaction output[fund vout]
 	// output:
-	//   [0]:settlement script
-	//   [1]:p2wpkh (option)
-	var val1 int64
-	var val2 int64
-	var pub1 *btcec.PublicKey
-	var pub2 *btcec.PublicKey
-	if isA {
-		val1 = rate.amta
-		val2 = rate.amtb
-		pub1 = d.puba
-		pub2 = d.pubb
-	} else {
-		val1 = rate.amtb
-		val2 = rate.amta
-		pub1 = d.pubb
-		pub2 = d.puba
-	}
-	if val1 <= 0 {
-		return nil
-	}
+	//   [0]:payout pkScript a (not dust)
+	//   [1]:payout pkScript b (not dust)
 	tx := wire.NewMsgTx(2)
 	txid := d.FundTx().TxHash()
-	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&txid, 0), nil, nil))
-	pub := &btcec.PublicKey{}
-	pub.X, pub.Y = btcec.S256().Add(rate.key.X, rate.key.Y, pub1.X, pub1.Y)
-	pkScript := P2WSHpkScript(SettlementScript(pub, pub2))
-	txout1 := wire.NewTxOut(val1, pkScript)
-	tx.AddTxOut(txout1)
-	if val2 > 0 {
-		txout2 := wire.NewTxOut(val2, P2WPKHpkScript(pub2))
-		tx.AddTxOut(txout2)
-	}
-	if d.isA != isA {
-		rate.txid, _ = chainhash.NewHashFromStr(tx.TxHash().String())
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&txid, d.FundVout()), nil, nil))
+	vala, valb := d.settlementValues(rate)
+	// The dust outputs are folded into fee.
+	if pkScript := d.PayoutScript(true); !IsDust(vala, pkScript) {
+		tx.AddTxOut(wire.NewTxOut(vala, pkScript))
+	}
+	if pkScript := d.PayoutScript(false); !IsDust(valb, pkScript) {
+		tx.AddTxOut(wire.NewTxOut(valb, pkScript))
 	}
+	rate.txid, _ = chainhash.NewHashFromStr(tx.TxHash().String())
 	return tx
 }
 
+// settlementValues returns the output values of A and B for rate.
+// The settlement fee over the weight of the transaction is returned to the payers.
+func (d *Dlc) settlementValues(rate *Rate) (int64, int64) {
+	fee := WeightToFee(d.SettlementTxWeight(nil), d.sefee)
+	feea, feeb := fee-fee/2, fee/2
+	if d.payers != nil {
+		feea, feeb, _ = splitFee(fee, d.payers.Settlement, d.offerA)
+	}
+	return rate.amta + d.sfeea - feea, rate.amtb + d.sfeeb - feeb
+}
+
 // RefundTx returns a refund transaction.
 func (d *Dlc) RefundTx() *wire.MsgTx {
 	// refund transaction
 	// input:
-	//   [0]:fund transaction output[0]
+	//   [0]:fund transaction output[fund vout]
 	//       Sequence (0xfeffffff LE)
 	// output:
-	//   [0]:p2wpkh a
-	//   [1]:p2wpkh b
+	//   [0]:payout pkScript a (not dust)
+	//   [1]:payout pkScript b (not dust)
 	// locktime:
 	//    Value decided by contract.
 	tx := wire.NewMsgTx(2)
 	txid := d.FundTx().TxHash()
-	txin := wire.NewTxIn(wire.NewOutPoint(&txid, 0), nil, nil)
+	txin := wire.NewTxIn(wire.NewOutPoint(&txid, d.FundVout()), nil, nil)
 	txin.Sequence-- // max(0xffffffff-0x01)
 	if d.rsigna != nil && d.rsignb != nil {
-		tw := wire.TxWitness{}
-		tw = append(tw, []byte{})
-		tw = append(tw, d.rsigna)
-		tw = append(tw, d.rsignb)
-		tw = append(tw, d.FundScript())
-		txin.Witness = tw
+		txin.Witness, _ = d.FundWitness(d.rsigna, d.rsignb)
 	}
 	tx.AddTxIn(txin)
-	tx.AddTxOut(wire.NewTxOut(d.famta, P2WPKHpkScript(d.puba)))
-	tx.AddTxOut(wire.NewTxOut(d.famtb, P2WPKHpkScript(d.pubb)))
+	// The dust outputs are folded into fee.
+	if amt, pkScript := d.RefundAmount(true), d.PayoutScript(true); !IsDust(amt, pkScript) {
+		tx.AddTxOut(wire.NewTxOut(amt, pkScript))
+	}
+	if amt, pkScript := d.RefundAmount(false), d.PayoutScript(false); !IsDust(amt, pkScript) {
+		tx.AddTxOut(wire.NewTxOut(amt, pkScript))
+	}
 	tx.LockTime = d.locktime
 	return tx
 }
 
-// Verify verifies signature for rate.
-func (d *Dlc) Verify(rate *Rate, isA bool, sign []byte, pub *btcec.PublicKey) error {
-	// verify settlement transaction
-	// parse signature
-	s, err := btcec.ParseDERSignature(sign, btcec.S256())
+// Verify verifies the adaptor signature of settlement transaction for rate
+// encrypted to the rate key, and sets it for rate.
+// In taproot mode, it is the Schnorr adaptor signature for the fund leaf,
+// otherwise the ECDSA adaptor signature for the fund script.
+func (d *Dlc) Verify(rate *Rate, asig []byte, pub *btcec.PublicKey) error {
+	hash, err := d.FundSigHash(d.SettlementTx(rate), false)
 	if err != nil {
 		return err
 	}
-	// settlement transaction
-	tx := d.SettlementTx(rate, isA)
-	// verify
-	sighashes := txscript.NewTxSigHashes(tx)
-	script := d.FundScript()
-	amt := d.FundAmount() + d.SettlementFee()
-	hash, err := txscript.CalcWitnessSigHash(script, sighashes, txscript.SigHashAll,
-		tx, 0, amt)
+	if d.taproot {
+		err = schnorr.EncVerify(pub, hash, rate.key, asig)
+	} else {
+		err = ecdsa.EncVerify(pub, hash, rate.key, asig)
+	}
 	if err != nil {
 		return err
 	}
-	verify := s.Verify(hash, pub)
-	if !verify {
-		return fmt.Errorf("verify fail : %v", verify)
-	}
-	// set signature for rate
-	rate.rsign = sign
+	rate.rsign = asig
 	return nil
 }
 
-// VerifyRefundTx verifies the refund transaction.
-func (d *Dlc) VerifyRefundTx(sign []byte, pub *btcec.PublicKey) error {
-	// parse signature
-	s, err := btcec.ParseDERSignature(sign, btcec.S256())
+// AdaptedSign returns the signature of the other for settlement transaction of rate,
+// which is decrypted from the adaptor signature received with the fixed messages sign.
+func (d *Dlc) AdaptedSign(rate *Rate) ([]byte, error) {
+	if rate.rsign == nil || rate.msign == nil {
+		return nil, fmt.Errorf("adaptor signature or messages sign is not set : %v", rate)
+	}
+	var sign []byte
+	var err error
+	secret := intToBytes(rate.msign)
+	if d.taproot {
+		sign, err = schnorr.Adapt(rate.rsign, secret)
+	} else {
+		sign, err = ecdsa.Adapt(rate.rsign, secret)
+		sign = append(sign, byte(txscript.SigHashAll))
+	}
 	if err != nil {
-		return err
+		return nil, err
+	}
+	err = d.verifyFundSign(d.SettlementTx(rate), sign, d.PublicKey(!d.isA))
+	if err != nil {
+		return nil, err
+	}
+	return sign, nil
+}
+
+// FundSigHash returns the signature hash of tx spending fund output at txin 0.
+// In taproot mode, if keyPath, it is for MuSig2 key, otherwise for the fund leaf.
+// Otherwise it is the BIP143 signature hash with SIGHASH_ALL for the fund script.
+func (d *Dlc) FundSigHash(tx *wire.MsgTx, keyPath bool) ([]byte, error) {
+	return d.fundSigHash(tx, 0, []*wire.TxOut{d.fundPrevOut()}, keyPath)
+}
+
+// fundSigHash returns the signature hash of tx spending fund output at txin idx,
+// where prevOuts are the outputs spent by all txins used in taproot mode.
+func (d *Dlc) fundSigHash(tx *wire.MsgTx, idx int, prevOuts []*wire.TxOut,
+	keyPath bool) ([]byte, error) {
+	if d.taproot {
+		if d.fundPkScript() == nil {
+			return nil, fmt.Errorf("not found taproot fund output")
+		}
+		var leaf []byte
+		if !keyPath {
+			leaf = d.FundLeafScript()
+		}
+		return TaprootSigHash(tx, idx, prevOuts, leaf)
 	}
-	// verify
 	script := d.FundScript()
-	if script == nil {
-		return fmt.Errorf("not found fund script")
+	if script == nil || keyPath {
+		return nil, fmt.Errorf("not found fund script")
+	}
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("illegal txin index : %d", idx)
 	}
-	tx := d.RefundTx()
 	sighashes := txscript.NewTxSigHashes(tx)
 	amt := d.FundAmount() + d.SettlementFee()
-	hash, err := txscript.CalcWitnessSigHash(script, sighashes, txscript.SigHashAll,
-		tx, 0, amt)
-	if err != nil {
-		return err
+	return txscript.CalcWitnessSigHash(script, sighashes, txscript.SigHashAll,
+		tx, idx, amt)
+}
+
+// fundPrevOut returns the fund output.
+func (d *Dlc) fundPrevOut() *wire.TxOut {
+	return wire.NewTxOut(d.FundAmount()+d.SettlementFee(), d.fundPkScript())
+}
+
+// FundWitness returns the witness of tx spending fund output from the signatures of A and B.
+func (d *Dlc) FundWitness(signa, signb []byte) (wire.TxWitness, error) {
+	if d.taproot {
+		return d.TapscriptWitness(signa, signb)
 	}
-	verify := s.Verify(hash, pub)
-	if !verify {
-		return fmt.Errorf("verify fail : %v", verify)
+	script := d.FundScript()
+	if script == nil {
+		return nil, fmt.Errorf("not found fund script")
 	}
-	return nil
+	// The multisig signatures are in order of A and B.
+	return wire.TxWitness{[]byte{}, signa, signb, script}, nil
 }
 
-// SettlementToTx returns the transaction to send to pkScript.
-func (d *Dlc) SettlementToTx(rate *Rate, isA bool, pkScript []byte, efee int64) (
-	*wire.MsgTx, int64, []byte, error) {
-	// send settlement transaction to pkScript
-	// input:
-	//   [0]:settlement transaction[0]
-	// output:
-	//   [0]:pkScript
-	var val1 int64
-	var pub1 *btcec.PublicKey
-	var pub2 *btcec.PublicKey
-	if isA {
-		val1 = rate.amta
-		pub1 = d.puba
-		pub2 = d.pubb
-	} else {
-		val1 = rate.amtb
-		pub1 = d.pubb
-		pub2 = d.puba
-	}
-	// txid
-	stx := d.SettlementTx(rate, isA)
-	if stx == nil {
-		return nil, -1, nil, fmt.Errorf("settlement transaction is nil")
-	}
-	txid := stx.TxHash()
-	// fee
-	fee := int64(216+len(pkScript)) * efee // 216 bytes + pkScript
-	// txout value
-	val := val1 - fee
-	if val < 0 {
-		return nil, -1, nil, fmt.Errorf("val is minus. val:%d, fee:%d", val, fee)
-	}
-	// transaction
-	tx := wire.NewMsgTx(2)
-	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&txid, 0), nil, nil))
-	txout := wire.NewTxOut(val, pkScript)
-	tx.AddTxOut(txout)
-	// script
-	pub := &btcec.PublicKey{}
-	pub.X, pub.Y = btcec.S256().Add(rate.key.X, rate.key.Y, pub1.X, pub1.Y)
-	script := SettlementScript(pub, pub2)
-	return tx, val1, script, nil
+// VerifyRefundTx verifies the refund transaction.
+func (d *Dlc) VerifyRefundTx(sign []byte, pub *btcec.PublicKey) error {
+	return d.verifyFundSign(d.RefundTx(), sign, pub)
 }
 
 // P2WPKHpkScript creates P2WPKH pkScript
@@ -0,0 +1,318 @@
+// Package dlc project encoding.go
+package dlc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"ec"
+)
+
+// hexBytes is the bytes encoded as hex string in JSON.
+// nil is encoded as null to keep it from empty bytes.
+type hexBytes []byte
+
+// MarshalJSON returns the hex string of bytes.
+func (h hexBytes) MarshalJSON() ([]byte, error) {
+	if h == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(hex.EncodeToString(h))
+}
+
+// UnmarshalJSON sets the bytes of hex string.
+func (h *hexBytes) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*h = nil
+		return nil
+	}
+	var str string
+	err := json.Unmarshal(data, &str)
+	if err != nil {
+		return err
+	}
+	bs, err := hex.DecodeString(str)
+	if err != nil {
+		return err
+	}
+	*h = bs
+	return nil
+}
+
+// DecodeJSON decodes data into v strictly.
+// The unknown fields and the data after the value are errors.
+func DecodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err != nil {
+		return err
+	}
+	if dec.More() {
+		return fmt.Errorf("unexpected data after json")
+	}
+	_, err = dec.Token()
+	if err != io.EOF {
+		return fmt.Errorf("unexpected data after json")
+	}
+	return nil
+}
+
+// encoder writes the binary encoding.
+// The integers are little endian and the counts and lengths are varints.
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) putUint32(v uint32) {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	e.Write(b)
+}
+
+func (e *encoder) putUint64(v uint64) {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	e.Write(b)
+}
+
+func (e *encoder) putInt64(v int64) {
+	e.putUint64(uint64(v))
+}
+
+func (e *encoder) putBool(v bool) {
+	if v {
+		e.WriteByte(1)
+	} else {
+		e.WriteByte(0)
+	}
+}
+
+func (e *encoder) putCount(n int) {
+	wire.WriteVarInt(e, 0, uint64(n))
+}
+
+func (e *encoder) putString(s string) {
+	wire.WriteVarString(e, 0, s)
+}
+
+// putHeader writes 0x00 for nil list or 0x01 and the count of list.
+func (e *encoder) putHeader(ok bool, n int) {
+	e.putBool(ok)
+	if ok {
+		e.putCount(n)
+	}
+}
+
+// putBytes writes 0x00 for nil or 0x01 and the length and bytes.
+func (e *encoder) putBytes(b []byte) {
+	if b == nil {
+		e.WriteByte(0)
+		return
+	}
+	e.WriteByte(1)
+	wire.WriteVarBytes(e, 0, b)
+}
+
+// putHash writes 0x00 for empty or 0x01 and the hash of string.
+func (e *encoder) putHash(s string) {
+	if s == "" {
+		e.WriteByte(0)
+		return
+	}
+	hash, _ := chainhash.NewHashFromStr(s)
+	e.WriteByte(1)
+	e.Write(hash[:])
+}
+
+// decoder reads the binary encoding.
+// The first error is kept and the rest reads return zero values.
+type decoder struct {
+	r   *bytes.Reader
+	err error
+}
+
+func newDecoder(data []byte) *decoder {
+	return &decoder{r: bytes.NewReader(data)}
+}
+
+func (d *decoder) read(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	b := make([]byte, n)
+	_, d.err = io.ReadFull(d.r, b)
+	return b
+}
+
+func (d *decoder) getUint32() uint32 {
+	b := d.read(4)
+	if d.err != nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (d *decoder) getUint64() uint64 {
+	b := d.read(8)
+	if d.err != nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+func (d *decoder) getInt64() int64 {
+	return int64(d.getUint64())
+}
+
+// getFlag reads 0x00 or 0x01.
+func (d *decoder) getFlag() bool {
+	b := d.read(1)
+	if d.err != nil {
+		return false
+	}
+	if b[0] > 1 {
+		d.err = fmt.Errorf("illegal flag : %#x", b[0])
+		return false
+	}
+	return b[0] == 1
+}
+
+func (d *decoder) getBool() bool {
+	return d.getFlag()
+}
+
+// getCount reads a count, which is not larger than the rest bytes
+// because every element takes one byte at least.
+func (d *decoder) getCount() int {
+	if d.err != nil {
+		return 0
+	}
+	n, err := wire.ReadVarInt(d.r, 0)
+	if err != nil {
+		d.err = err
+		return 0
+	}
+	if n > uint64(d.r.Len()) {
+		d.err = fmt.Errorf("count is too large : %d", n)
+		return 0
+	}
+	return int(n)
+}
+
+// getHeader reads the header of list, and returns false for nil list.
+func (d *decoder) getHeader() (bool, int) {
+	if !d.getFlag() {
+		return false, 0
+	}
+	return true, d.getCount()
+}
+
+func (d *decoder) getString() string {
+	n := d.getCount()
+	return string(d.read(n))
+}
+
+func (d *decoder) getBytes() []byte {
+	if !d.getFlag() {
+		return nil
+	}
+	n := d.getCount()
+	return d.read(n)
+}
+
+func (d *decoder) getHash() string {
+	if !d.getFlag() {
+		return ""
+	}
+	b := d.read(chainhash.HashSize)
+	if d.err != nil {
+		return ""
+	}
+	hash, _ := chainhash.NewHash(b)
+	return hash.String()
+}
+
+// finish returns the error, or an error if bytes are left.
+func (d *decoder) finish() error {
+	if d.err != nil {
+		return d.err
+	}
+	if d.r.Len() > 0 {
+		return fmt.Errorf("unexpected data after encoding : %d bytes", d.r.Len())
+	}
+	return nil
+}
+
+// pubToBytes returns the compressed public key, or nil for nil.
+func pubToBytes(pub *btcec.PublicKey) hexBytes {
+	if pub == nil {
+		return nil
+	}
+	return pub.SerializeCompressed()
+}
+
+// bytesToPub parses the compressed public key, or returns nil for nil.
+func bytesToPub(b hexBytes) (*btcec.PublicKey, error) {
+	if b == nil {
+		return nil, nil
+	}
+	if len(b) != btcec.PubKeyBytesLenCompressed {
+		return nil, fmt.Errorf("illegal public key length : %d", len(b))
+	}
+	return ec.Parse(b)
+}
+
+// intToBytes returns the 32 bytes of the scalar, or nil for nil.
+func intToBytes(x *big.Int) hexBytes {
+	if x == nil {
+		return nil
+	}
+	b := make([]byte, 32)
+	xb := x.Bytes()
+	copy(b[32-len(xb):], xb)
+	return b
+}
+
+// bytesToInt parses the 32 bytes scalar less than N, or returns nil for nil.
+func bytesToInt(b hexBytes) (*big.Int, error) {
+	if b == nil {
+		return nil, nil
+	}
+	if len(b) != 32 {
+		return nil, fmt.Errorf("illegal scalar length : %d", len(b))
+	}
+	x := new(big.Int).SetBytes(b)
+	if x.Cmp(btcec.S256().N) >= 0 {
+		return nil, fmt.Errorf("scalar is out of range : %x", b)
+	}
+	return x, nil
+}
+
+// hashToStr returns the string of hash, or empty for nil.
+func hashToStr(hash *chainhash.Hash) string {
+	if hash == nil {
+		return ""
+	}
+	return hash.String()
+}
+
+// strToHash parses the 64 characters hash, or returns nil for empty.
+func strToHash(s string) (*chainhash.Hash, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if len(s) != 2*chainhash.HashSize {
+		return nil, fmt.Errorf("illegal hash length : %s", s)
+	}
+	return chainhash.NewHashFromStr(s)
+}
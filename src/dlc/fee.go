@@ -0,0 +1,137 @@
+// Package dlc project fee.go
+package dlc
+
+import (
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/wire"
+
+	"schnorr"
+)
+
+// Sizes of transaction parts (byte)
+const (
+	// SigSize is the maximum size of DER signature with sighash type.
+	SigSize = 73
+	// PubKeySize is the size of compressed public key.
+	PubKeySize = 33
+	// txInBaseSize is the size of outpoint, empty signature script and sequence.
+	txInBaseSize = 32 + 4 + 1 + 4
+	// txBaseSize is the size of version and locktime.
+	txBaseSize = 4 + 4
+	// witnessFlagSize is the size of segwit marker and flag.
+	witnessFlagSize = 2
+)
+
+// WitnessScaleFactor is the weight of a non-witness byte.
+const WitnessScaleFactor = 4
+
+// TxWeight returns the weight of transaction without inputs and outputs.
+func TxWeight(nins, nouts int) int64 {
+	size := txBaseSize + wire.VarIntSerializeSize(uint64(nins)) +
+		wire.VarIntSerializeSize(uint64(nouts))
+	return int64(size*WitnessScaleFactor + witnessFlagSize)
+}
+
+// InputWeight returns the weight of txin with witness items of the sizes.
+func InputWeight(items ...int) int64 {
+	witness := wire.VarIntSerializeSize(uint64(len(items)))
+	for _, n := range items {
+		witness += wire.VarIntSerializeSize(uint64(n)) + n
+	}
+	return int64(txInBaseSize*WitnessScaleFactor + witness)
+}
+
+// OutputWeight returns the weight of txout to pkScript.
+func OutputWeight(pkScript []byte) int64 {
+	size := 8 + wire.VarIntSerializeSize(uint64(len(pkScript))) + len(pkScript)
+	return int64(size * WitnessScaleFactor)
+}
+
+// P2WPKHInputWeight returns the weight of txin spending P2WPKH.
+func P2WPKHInputWeight() int64 {
+	return InputWeight(SigSize, PubKeySize)
+}
+
+// WeightToFee returns the fee (satoshi) for weight at fee rate (satoshi/vbyte).
+func WeightToFee(weight, feerate int64) int64 {
+	return (weight*feerate + WitnessScaleFactor - 1) / WitnessScaleFactor
+}
+
+// templatePub is the public key to make scripts for weight.
+// The sizes of scripts do not depend on public keys.
+var templatePub = func() *btcec.PublicKey {
+	pub := &btcec.PublicKey{}
+	pub.Curve = btcec.S256()
+	pub.X, pub.Y = btcec.S256().Gx, btcec.S256().Gy
+	return pub
+}()
+
+// fundInputWeight returns the weight of txin spending fund transaction.
+func (d *Dlc) fundInputWeight() int64 {
+	return fundTxInWeight(d.taproot)
+}
+
+// fundTxInWeight returns the weight of txin spending fund output of taproot mode or not.
+func fundTxInWeight(taproot bool) int64 {
+	if taproot {
+		// witness: <sign b> <sign a> <fund leaf script> <control block>
+		script := fundLeafScript(templatePub, templatePub)
+		return InputWeight(schnorr.SigSize, schnorr.SigSize, len(script), controlBlockSize)
+	}
+	// witness: <empty> <sign a> <sign b> <fund script>
+	script := fundScript(templatePub, templatePub)
+	return InputWeight(0, SigSize, SigSize, len(script))
+}
+
+// closeInputWeight returns the weight of txin spending fund transaction cooperatively.
+func (d *Dlc) closeInputWeight() int64 {
+	if d.taproot {
+		// witness: <MuSig2 sign>
+		return InputWeight(schnorr.SigSize)
+	}
+	return d.fundInputWeight()
+}
+
+// FundBaseWeight returns the weight of fund transaction shared by A and B,
+// which is the transaction without inputs and outputs and the fund output,
+// and the txin spending previous fund output in rollover.
+func (d *Dlc) FundBaseWeight() int64 {
+	weight := TxWeight(0, 0) + d.rolloverInputWeight()
+	if d.taproot {
+		return weight + OutputWeight(P2TRpkScript(templatePub))
+	}
+	script := fundScript(templatePub, templatePub)
+	return weight + OutputWeight(P2WSHpkScript(script))
+}
+
+// maxPayoutScript is the pkScript of the maximum size for payout.
+var maxPayoutScript = make([]byte, MaxPayoutScriptSize)
+
+// SettlementTxWeight returns the weight of settlement transaction,
+// which pays to the payout scripts of A and B.
+// pkScript is for the payout script not known yet.
+func (d *Dlc) SettlementTxWeight(pkScript []byte) int64 {
+	weight := TxWeight(1, 2) + d.fundInputWeight()
+	for _, isA := range []bool{true, false} {
+		if d.PublicKey(isA) != nil {
+			weight += OutputWeight(d.PayoutScript(isA))
+		} else {
+			weight += OutputWeight(pkScript)
+		}
+	}
+	return weight
+}
+
+// RefundTxWeight returns the weight of refund transaction.
+// The payout scripts not known yet are the maximum size.
+func (d *Dlc) RefundTxWeight() int64 {
+	weight := TxWeight(1, 2) + d.fundInputWeight()
+	for _, isA := range []bool{true, false} {
+		pkScript := maxPayoutScript
+		if d.PublicKey(isA) != nil {
+			pkScript = d.PayoutScript(isA)
+		}
+		weight += OutputWeight(pkScript)
+	}
+	return weight
+}
@@ -2,10 +2,10 @@
 package dlc
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
-	"math"
 	"math/big"
-	"reflect"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -17,7 +17,39 @@ import (
 func (d *Dlc) SetGameConditions(height, length int) {
 	d.height = height
 	d.length = length
-	d.locktime = uint32(d.length + 144)
+	d.locktime = uint32(d.height + MinRefundGap)
+}
+
+// SetPayoutFunction sets the payout function.
+func (d *Dlc) SetPayoutFunction(f PayoutFunction) {
+	d.payout = f
+	d.resetRates()
+}
+
+// SetRoundingIntervals sets the rounding intervals of payout.
+// The outcomes of the same rounded payout share the prefixes.
+func (d *Dlc) SetRoundingIntervals(ris []RoundingInterval) error {
+	err := checkRoundingIntervals(ris)
+	if err != nil {
+		return err
+	}
+	d.rounding = ris
+	d.resetRates()
+	return nil
+}
+
+// RoundingIntervals returns the rounding intervals of payout.
+func (d *Dlc) RoundingIntervals() []RoundingInterval {
+	return d.rounding
+}
+
+// PayoutFunction returns the payout function.
+// If it is not set, the original payout function of the game is returned.
+func (d *Dlc) PayoutFunction() PayoutFunction {
+	if d.payout != nil {
+		return d.payout
+	}
+	return QuarterPayout(d.FundAmount(), d.length)
 }
 
 // Rates returns rate array.
@@ -26,61 +58,67 @@ func (d *Dlc) Rates() []*Rate {
 	if d.rates != nil {
 		return d.rates
 	}
-	// original calc
 	rates := []*Rate{}
 	amount := d.FundAmount()
-	// quarter
-	q := int64(math.Pow(float64(0x100), float64(d.length))) / 4
-	// The first quarter is won low and all will be paid low.
-	for x := int64(0); x < q; x++ {
-		msgs := make([][]byte, d.length)
-		for i := range msgs {
-			if i == d.length-1 {
-				msgs[i] = []byte{byte(x)}
-				continue
-			}
-			msgs[i] = nil
+	payout := d.PayoutFunction()
+	// number of outcomes
+	n := outcomes(d.length)
+	// The outcomes of the same payout are covered by the prefixes of digits.
+	// high is paid to a and low is paid to b.
+	for _, iv := range payoutIntervals(payout, n, amount, d.rounding) {
+		for _, prefix := range coverPrefixes(iv.begin, iv.end, DigitBase, d.length) {
+			msgs := prefixMessages(prefix, d.length)
+			rate := NewRate(msgs, iv.payout, amount-iv.payout)
+			rates = append(rates, rate)
 		}
-		rate := NewRate(msgs, 0, amount)
-		rates = append(rates, rate)
-	}
-	// The second and third quarters are paid linearly.
-	// high value is y, quarter is q, amount is A.
-	// y = a*x - b
-	// 0 = a*(q-1) - b
-	// A = a*(3*q) - b
-	// a = A/(2*q+1)
-	// b = a*(q-1)
-	a := float64(amount) / float64(2*q+1)
-	b := float64(a * float64(q-1))
-	for x := q; x < 3*q; x++ {
-		msgs := make([][]byte, d.length)
-		tmp := x
-		for i := range msgs {
-			msgs[i] = []byte{byte(tmp % 0x100)}
-			tmp = (tmp - tmp%0x100) / 0x100
+	}
+	// If the event is cancelled, each collateral is returned.
+	msgs := make([][]byte, d.length)
+	msgs[d.length-1] = oracle.CancelMessage
+	rates = append(rates, NewRate(msgs, d.famta, d.famtb))
+	// set cache
+	// The trie is not built if the rates overlap, which Validate reports.
+	d.rates = rates
+	d.trie, _ = newRateTrie(rates, d.length)
+	return d.rates
+}
+
+// PayoutTableHash returns the canonical hash of rates.
+// It returns error if the amounts of a rate are not the fund amount.
+func (d *Dlc) PayoutTableHash() ([]byte, error) {
+	// sha256 of
+	//   tag, number of rates and for each rate:
+	//     number of messages, messages (0x00 for nil or 0x01 + length + message),
+	//     amount a, amount b (int64 little endian)
+	rates := d.Rates()
+	amount := d.FundAmount()
+	h := sha256.New()
+	h.Write([]byte("dlc/payout-table/v1"))
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint32(b, uint32(len(rates)))
+	h.Write(b[:4])
+	for _, r := range rates {
+		if r.amta < 0 || r.amtb < 0 || r.amta+r.amtb != amount {
+			return nil, fmt.Errorf("illegal rate amount : %d, %d, %d", r.amta, r.amtb, amount)
 		}
-		high := int64(math.Round(a*float64(x) - b))
-		low := amount - high
-		rate := NewRate(msgs, high, low)
-		rates = append(rates, rate)
-	}
-	// The last quarter is won high and all will be paid high.
-	for x := q * 3; x < q*4; x++ {
-		msgs := make([][]byte, d.length)
-		for i := range msgs {
-			if i == d.length-1 {
-				msgs[i] = []byte{byte(x)}
+		binary.LittleEndian.PutUint32(b, uint32(len(r.msgs)))
+		h.Write(b[:4])
+		for _, m := range r.msgs {
+			if m == nil {
+				h.Write([]byte{0x00})
 				continue
 			}
-			msgs[i] = nil
+			h.Write([]byte{0x01})
+			binary.LittleEndian.PutUint32(b, uint32(len(m)))
+			h.Write(b[:4])
+			h.Write(m)
 		}
-		rate := NewRate(msgs, amount, 0)
-		rates = append(rates, rate)
+		binary.LittleEndian.PutUint64(b, uint64(r.amta))
+		h.Write(b)
+		binary.LittleEndian.PutUint64(b, uint64(r.amtb))
+		h.Write(b)
 	}
-	// set cache
-	d.rates = rates
-	return d.rates
+	return h.Sum(nil), nil
 }
 
 // SetOracleKeys sets the public key of oracle and the public keys of the message to the rate.
@@ -108,19 +146,44 @@ func (d *Dlc) SetOracleKeys(pub *btcec.PublicKey, keys []*btcec.PublicKey) {
 	d.okeys = keys
 }
 
+// HashMessages returns the messages of the digits of the block hash.
+func HashMessages(hash *chainhash.Hash) [][]byte {
+	msgs := [][]byte{}
+	for i := 0; i < chainhash.HashSize; i++ {
+		msgs = append(msgs, []byte{hash[i]})
+	}
+	return msgs
+}
+
 // SetOracleSigns sets oracle's signatures to rate and sets a fixed rate.
 func (d *Dlc) SetOracleSigns(hash *chainhash.Hash, signs []*big.Int) error {
+	msgs := HashMessages(hash)
+	err := d.setOracleSigns(msgs, signs)
+	if err != nil {
+		return err
+	}
+	d.hash = hash
+	return nil
+}
+
+// SetOracleCancelSigns sets oracle's signatures of the cancelled event
+// and sets the cancel rate as a fixed rate.
+func (d *Dlc) SetOracleCancelSigns(signs []*big.Int) error {
 	msgs := [][]byte{}
 	for i := 0; i < chainhash.HashSize; i++ {
-		msgs = append(msgs, []byte{hash[i]})
+		msgs = append(msgs, oracle.CancelMessage)
 	}
+	return d.setOracleSigns(msgs, signs)
+}
+
+func (d *Dlc) setOracleSigns(msgs [][]byte, signs []*big.Int) error {
 	if len(msgs) != len(signs) {
-		return fmt.Errorf("illegal parameters %v,%x", hash, signs)
+		return fmt.Errorf("illegal parameters %x,%x", msgs, signs)
 	}
 	// search fixed rate
-	rate := d.searchRate(msgs)
-	if rate == nil {
-		return fmt.Errorf("rate not found")
+	rate, err := d.searchRate(msgs)
+	if err != nil {
+		return err
 	}
 	// calc signature
 	sign := big.NewInt(0)
@@ -139,33 +202,39 @@ func (d *Dlc) SetOracleSigns(hash *chainhash.Hash, signs []*big.Int) error {
 	d.frate = rate
 	d.omsgs = msgs
 	d.osigns = signs
-	d.hash = hash
 	return nil
 }
 
+// OraclePublicKey returns the public key of oracle.
+func (d *Dlc) OraclePublicKey() *btcec.PublicKey {
+	return d.pubo
+}
+
+// OracleKeys returns the contract keys of oracle.
+func (d *Dlc) OracleKeys() []*btcec.PublicKey {
+	return d.okeys
+}
+
 // FixedRate returns a fixed rate.
 func (d *Dlc) FixedRate() *Rate {
 	return d.frate
 }
 
-func (d *Dlc) searchRate(msgs [][]byte) *Rate {
-	var rate *Rate
-	rates := d.Rates()
-	for _, r := range rates {
-		for i := d.length - 1; i >= 0; i-- {
-			if r.msgs[i] == nil || reflect.DeepEqual(r.msgs[i], msgs[i]) {
-				if i == 0 {
-					rate = r
-				}
-				continue
-			}
-			break
-		}
-		if rate != nil {
-			break
-		}
+// searchRate returns the rate whose prefix matches the messages.
+// The rate is found by the trie of rates in the number of digits.
+func (d *Dlc) searchRate(msgs [][]byte) (*Rate, error) {
+	if len(msgs) < d.length {
+		return nil, fmt.Errorf("messages are too short : %d", len(msgs))
+	}
+	trie, err := d.rateIndex()
+	if err != nil {
+		return nil, err
+	}
+	rate := trie.search(msgs, d.length)
+	if rate == nil {
+		return nil, fmt.Errorf("rate not found")
 	}
-	return rate
+	return rate, nil
 }
 
 // original function
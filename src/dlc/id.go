@@ -0,0 +1,42 @@
+// Package dlc project id.go
+package dlc
+
+import (
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// IDSize is the size of contract id.
+const IDSize = chainhash.HashSize
+
+// SetTemporaryID sets the temporary contract id derived from offer.
+func (d *Dlc) SetTemporaryID(id []byte) {
+	d.tempID = id
+}
+
+// TemporaryID returns the temporary contract id.
+func (d *Dlc) TemporaryID() []byte {
+	return d.tempID
+}
+
+// ContractID returns the contract id, which is the fund txid xor the temporary id
+// with the fund output index xored into the last 2 bytes.
+// It returns nil until the fund transaction is fixed by the public keys of both parties.
+// A party may add no txins, when the previous fund output in rollover covers its share.
+func (d *Dlc) ContractID() []byte {
+	if len(d.tempID) != IDSize || d.puba == nil || d.pubb == nil ||
+		d.FundScript() == nil || len(d.ownTxIns()) == 0 {
+		return nil
+	}
+	txid := d.FundTx().TxHash()
+	return contractID(&txid, d.FundVout(), d.tempID)
+}
+
+func contractID(txid *chainhash.Hash, vout uint32, tempID []byte) []byte {
+	id := make([]byte, IDSize)
+	for i := range id {
+		id[i] = txid[i] ^ tempID[i]
+	}
+	id[IDSize-2] ^= byte(vout >> 8)
+	id[IDSize-1] ^= byte(vout)
+	return id
+}
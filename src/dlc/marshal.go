@@ -0,0 +1,850 @@
+// Package dlc project marshal.go
+package dlc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// EncodingVersion is the version of binary and JSON encodings of Dlc and Rate.
+// Version 2 adds taproot mode, and version 1 is decoded as it is off.
+// Version 3 drops the settlement delay, and the settlement signatures are adaptor signatures.
+// Version 4 adds rollover.
+// Version 5 adds the txins and txouts of the other contracts in batch.
+const EncodingVersion = 5
+
+// checkEncodingVersion returns error if the version is not decodable.
+func checkEncodingVersion(version int) error {
+	if version < 1 || version > EncodingVersion {
+		return fmt.Errorf("unknown encoding version : %d", version)
+	}
+	return nil
+}
+
+// dlcData is the encoded dataset of Dlc.
+type dlcData struct {
+	Version  int                `json:"version"`  // encoding version
+	Famta    int64              `json:"famta"`    // fund amount a (satoshi)
+	Famtb    int64              `json:"famtb"`    // fund amount b (satoshi)
+	Fefee    int64              `json:"fefee"`    // fund estimate fee
+	Sefee    int64              `json:"sefee"`    // settlement estimate fee
+	Sfeea    int64              `json:"sfeea"`    // settlement fee a (satoshi)
+	Sfeeb    int64              `json:"sfeeb"`    // settlement fee b (satoshi)
+	Ffeea    int64              `json:"ffeea"`    // fund base fee a (satoshi)
+	Ffeeb    int64              `json:"ffeeb"`    // fund base fee b (satoshi)
+	Payers   *FeePayers         `json:"payers"`   // fee payers
+	OfferA   bool               `json:"offera"`   // is the offerer a?
+	IsA      bool               `json:"isa"`      // is this contract a's?
+	TempID   hexBytes           `json:"tempid"`   // temporary contract id
+	Locktime uint32             `json:"locktime"` // refund transaction locktime
+	Delay    uint32             `json:"delay"`    // CSV delay of settlement script before version 3, which must be 0
+	Puba     hexBytes           `json:"puba"`     // public key a
+	Pubb     hexBytes           `json:"pubb"`     // public key b
+	Atxins   []*txinData        `json:"atxins"`   // fund txins a
+	Btxins   []*txinData        `json:"btxins"`   // fund txins b
+	Atxouts  []*txoutData       `json:"atxouts"`  // fund change txouts a
+	Btxouts  []*txoutData       `json:"btxouts"`  // fund change txouts b
+	Fserial  uint64             `json:"fserial"`  // fund output serial id
+	Pscripta hexBytes           `json:"pscripta"` // payout pkScript a
+	Pscriptb hexBytes           `json:"pscriptb"` // payout pkScript b
+	Rsigna   hexBytes           `json:"rsigna"`   // refund signature a
+	Rsignb   hexBytes           `json:"rsignb"`   // refund signature b
+	Pubo     hexBytes           `json:"pubo"`     // oracle public key
+	Okeys    []hexBytes         `json:"okeys"`    // oracle contract keys
+	Omsgs    []hexBytes         `json:"omsgs"`    // oracle fixed messages
+	Osigns   []hexBytes         `json:"osigns"`   // oracle fixed signs
+	Rates    []*rateData        `json:"rates"`    // rate list
+	Payout   *PayoutDescriptor  `json:"payout"`   // payout function
+	Rounding []RoundingInterval `json:"rounding"` // rounding intervals of payout
+	Frate    int                `json:"frate"`    // index of fixed rate, -1 if not fixed
+	Height   int                `json:"height"`   // block height
+	Length   int                `json:"length"`   // target length
+	Hash     string             `json:"hash"`     // block hash
+	Taproot  bool               `json:"taproot"`  // taproot mode
+	Rollover *Rollover          `json:"rollover"` // previous fund output in rollover
+	Otxins   []*txinData        `json:"otxins"`   // fund txins of the other contracts in batch
+	Otxouts  []*txoutData       `json:"otxouts"`  // fund txouts of the other contracts in batch
+}
+
+// txinData is the encoded dataset of FundTxIn.
+type txinData struct {
+	Serial   uint64     `json:"serial"`   // serial id
+	Txid     string     `json:"txid"`     // previous txid
+	Vout     uint32     `json:"vout"`     // previous output index
+	Sequence uint32     `json:"sequence"` // sequence
+	Script   hexBytes   `json:"script"`   // signature script
+	Witness  []hexBytes `json:"witness"`  // witness
+}
+
+// txoutData is the encoded dataset of FundTxOut.
+type txoutData struct {
+	Serial   uint64   `json:"serial"`   // serial id
+	Value    int64    `json:"value"`    // value (satoshi)
+	PkScript hexBytes `json:"pkscript"` // pkScript
+}
+
+// rateData is the encoded dataset of Rate.
+type rateData struct {
+	Version int        `json:"version,omitempty"` // encoding version of a single rate
+	Msgs    []hexBytes `json:"msgs"`              // settlement messages, null for any
+	Amta    int64      `json:"amta"`              // settlement amount a
+	Amtb    int64      `json:"amtb"`              // settlement amount b
+	Key     hexBytes   `json:"key"`               // settlement messages public key
+	Rsign   hexBytes   `json:"rsign"`             // signature received
+	Msign   hexBytes   `json:"msign"`             // fixed messages sign
+	Txid    string     `json:"txid"`              // settlement txid
+}
+
+// MarshalJSON returns the JSON encoding of Dlc.
+func (d *Dlc) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.toData())
+}
+
+// UnmarshalJSON sets the Dlc of JSON encoding.
+// The unknown fields and the other versions are errors.
+func (d *Dlc) UnmarshalJSON(data []byte) error {
+	dd := &dlcData{}
+	err := DecodeJSON(data, dd)
+	if err != nil {
+		return err
+	}
+	return d.fromData(dd)
+}
+
+// MarshalBinary returns the binary encoding of Dlc.
+func (d *Dlc) MarshalBinary() ([]byte, error) {
+	e := &encoder{}
+	d.toData().encode(e)
+	return e.Bytes(), nil
+}
+
+// UnmarshalBinary sets the Dlc of binary encoding.
+// The other versions and the bytes after the encoding are errors.
+func (d *Dlc) UnmarshalBinary(data []byte) error {
+	dec := newDecoder(data)
+	dd := &dlcData{}
+	dd.decode(dec)
+	err := dec.finish()
+	if err != nil {
+		return err
+	}
+	return d.fromData(dd)
+}
+
+// MarshalJSON returns the JSON encoding of Rate.
+func (r *Rate) MarshalJSON() ([]byte, error) {
+	rd := r.toData()
+	rd.Version = EncodingVersion
+	return json.Marshal(rd)
+}
+
+// UnmarshalJSON sets the Rate of JSON encoding.
+// The unknown fields and the other versions are errors.
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	rd := &rateData{}
+	err := DecodeJSON(data, rd)
+	if err != nil {
+		return err
+	}
+	err = checkEncodingVersion(rd.Version)
+	if err != nil {
+		return err
+	}
+	return r.fromData(rd)
+}
+
+// MarshalBinary returns the binary encoding of Rate.
+func (r *Rate) MarshalBinary() ([]byte, error) {
+	e := &encoder{}
+	e.putUint32(EncodingVersion)
+	r.toData().encode(e)
+	return e.Bytes(), nil
+}
+
+// UnmarshalBinary sets the Rate of binary encoding.
+// The other versions and the bytes after the encoding are errors.
+func (r *Rate) UnmarshalBinary(data []byte) error {
+	dec := newDecoder(data)
+	version := dec.getUint32()
+	rd := &rateData{}
+	rd.decode(dec)
+	err := dec.finish()
+	if err != nil {
+		return err
+	}
+	err = checkEncodingVersion(int(version))
+	if err != nil {
+		return err
+	}
+	return r.fromData(rd)
+}
+
+// toData returns the encoded dataset of Dlc.
+func (d *Dlc) toData() *dlcData {
+	dd := &dlcData{}
+	dd.Version = EncodingVersion
+	dd.Famta, dd.Famtb = d.famta, d.famtb
+	dd.Fefee, dd.Sefee = d.fefee, d.sefee
+	dd.Sfeea, dd.Sfeeb = d.sfeea, d.sfeeb
+	dd.Ffeea, dd.Ffeeb = d.ffeea, d.ffeeb
+	dd.Payers = d.payers
+	dd.OfferA, dd.IsA = d.offerA, d.isA
+	dd.TempID = d.tempID
+	dd.Locktime = d.locktime
+	dd.Puba, dd.Pubb = pubToBytes(d.puba), pubToBytes(d.pubb)
+	dd.Atxins, dd.Btxins = txinsToData(d.atxins), txinsToData(d.btxins)
+	dd.Atxouts, dd.Btxouts = txoutsToData(d.atxouts), txoutsToData(d.btxouts)
+	dd.Fserial = d.fserial
+	dd.Pscripta, dd.Pscriptb = d.pscripta, d.pscriptb
+	dd.Rsigna, dd.Rsignb = d.rsigna, d.rsignb
+	dd.Pubo = pubToBytes(d.pubo)
+	if d.okeys != nil {
+		dd.Okeys = []hexBytes{}
+		for _, key := range d.okeys {
+			dd.Okeys = append(dd.Okeys, pubToBytes(key))
+		}
+	}
+	if d.omsgs != nil {
+		dd.Omsgs = []hexBytes{}
+		for _, m := range d.omsgs {
+			dd.Omsgs = append(dd.Omsgs, m)
+		}
+	}
+	if d.osigns != nil {
+		dd.Osigns = []hexBytes{}
+		for _, s := range d.osigns {
+			dd.Osigns = append(dd.Osigns, intToBytes(s))
+		}
+	}
+	dd.Frate = -1
+	if d.rates != nil {
+		dd.Rates = []*rateData{}
+		for i, r := range d.rates {
+			dd.Rates = append(dd.Rates, r.toData())
+			if r == d.frate {
+				dd.Frate = i
+			}
+		}
+	}
+	if d.payout != nil {
+		dd.Payout = d.payout.Descriptor()
+	}
+	dd.Rounding = d.rounding
+	dd.Height, dd.Length = d.height, d.length
+	dd.Hash = hashToStr(d.hash)
+	dd.Taproot = d.taproot
+	dd.Rollover = d.roll
+	dd.Otxins, dd.Otxouts = txinsToData(d.otxins), txoutsToData(d.otxouts)
+	return dd
+}
+
+// fromData sets the Dlc of encoded dataset after checking it.
+// The Dlc is not changed on error.
+func (d *Dlc) fromData(dd *dlcData) error {
+	err := checkEncodingVersion(dd.Version)
+	if err != nil {
+		return err
+	}
+	// The settlement transactions of the delay are not supported since version 3.
+	if dd.Delay != 0 {
+		return fmt.Errorf("settlement delay of version %d is not supported : %d", dd.Version, dd.Delay)
+	}
+	nd := &Dlc{}
+	if dd.Famta < 0 || dd.Famtb < 0 || dd.Fefee < 0 || dd.Sefee < 0 ||
+		dd.Sfeea < 0 || dd.Sfeeb < 0 || dd.Ffeea < 0 || dd.Ffeeb < 0 {
+		return fmt.Errorf("illegal amounts or fees")
+	}
+	nd.famta, nd.famtb = dd.Famta, dd.Famtb
+	nd.fefee, nd.sefee = dd.Fefee, dd.Sefee
+	nd.sfeea, nd.sfeeb = dd.Sfeea, dd.Sfeeb
+	nd.ffeea, nd.ffeeb = dd.Ffeea, dd.Ffeeb
+	if dd.Payers == nil {
+		return fmt.Errorf("fee payers is nil")
+	}
+	for _, payer := range []string{dd.Payers.Fund, dd.Payers.Settlement, dd.Payers.Refund} {
+		_, _, err := splitFee(0, payer, dd.OfferA)
+		if err != nil {
+			return err
+		}
+	}
+	nd.payers = dd.Payers
+	nd.offerA, nd.isA = dd.OfferA, dd.IsA
+	if dd.TempID != nil && len(dd.TempID) != IDSize {
+		return fmt.Errorf("illegal temporary id : %x", dd.TempID)
+	}
+	nd.tempID = dd.TempID
+	if dd.Locktime != 0 {
+		err := nd.SetTimelocks(dd.Locktime)
+		if err != nil {
+			return err
+		}
+	}
+	nd.puba, err = bytesToPub(dd.Puba)
+	if err != nil {
+		return err
+	}
+	nd.pubb, err = bytesToPub(dd.Pubb)
+	if err != nil {
+		return err
+	}
+	nd.atxins, err = dataToTxins(dd.Atxins)
+	if err != nil {
+		return err
+	}
+	nd.btxins, err = dataToTxins(dd.Btxins)
+	if err != nil {
+		return err
+	}
+	nd.atxouts, err = dataToTxouts(dd.Atxouts)
+	if err != nil {
+		return err
+	}
+	nd.btxouts, err = dataToTxouts(dd.Btxouts)
+	if err != nil {
+		return err
+	}
+	nd.otxins, err = dataToTxins(dd.Otxins)
+	if err != nil {
+		return err
+	}
+	nd.otxouts, err = dataToTxouts(dd.Otxouts)
+	if err != nil {
+		return err
+	}
+	nd.fserial = dd.Fserial
+	if dd.Rollover != nil {
+		_, err = dd.Rollover.txIn()
+		if err != nil {
+			return err
+		}
+		nd.roll = dd.Rollover
+	}
+	err = nd.checkSerials()
+	if err != nil {
+		return err
+	}
+	for _, pkScript := range []hexBytes{dd.Pscripta, dd.Pscriptb} {
+		if pkScript == nil {
+			continue
+		}
+		err = CheckPayoutScript(pkScript)
+		if err != nil {
+			return err
+		}
+	}
+	nd.pscripta, nd.pscriptb = dd.Pscripta, dd.Pscriptb
+	nd.rsigna, nd.rsignb = dd.Rsigna, dd.Rsignb
+	nd.taproot = dd.Taproot
+	// game and payout
+	if dd.Length < 1 || dd.Length > chainhash.HashSize || dd.Height < 0 {
+		return fmt.Errorf("illegal game : %d, %d", dd.Height, dd.Length)
+	}
+	nd.height, nd.length = dd.Height, dd.Length
+	nd.hash, err = strToHash(dd.Hash)
+	if err != nil {
+		return err
+	}
+	if dd.Payout != nil {
+		nd.payout, err = NewPayoutFunction(dd.Payout)
+		if err != nil {
+			return err
+		}
+	}
+	err = checkRoundingIntervals(dd.Rounding)
+	if err != nil {
+		return err
+	}
+	nd.rounding = dd.Rounding
+	// oracle
+	nd.pubo, err = bytesToPub(dd.Pubo)
+	if err != nil {
+		return err
+	}
+	if dd.Okeys != nil {
+		if len(dd.Okeys) < nd.length {
+			return fmt.Errorf("oracle keys are too few : %d", len(dd.Okeys))
+		}
+		nd.okeys = []*btcec.PublicKey{}
+		for _, b := range dd.Okeys {
+			key, err := bytesToPub(b)
+			if err != nil {
+				return err
+			}
+			if key == nil {
+				return fmt.Errorf("oracle key is nil")
+			}
+			nd.okeys = append(nd.okeys, key)
+		}
+	}
+	if len(dd.Omsgs) != len(dd.Osigns) {
+		return fmt.Errorf("oracle messages and signs mismatch : %d, %d",
+			len(dd.Omsgs), len(dd.Osigns))
+	}
+	if dd.Omsgs != nil {
+		nd.omsgs = [][]byte{}
+		for _, m := range dd.Omsgs {
+			nd.omsgs = append(nd.omsgs, m)
+		}
+	}
+	if dd.Osigns != nil {
+		nd.osigns = []*big.Int{}
+		for _, b := range dd.Osigns {
+			s, err := bytesToInt(b)
+			if err != nil {
+				return err
+			}
+			if s == nil {
+				return fmt.Errorf("oracle sign is nil")
+			}
+			nd.osigns = append(nd.osigns, s)
+		}
+	}
+	// rates
+	if dd.Rates != nil {
+		nd.rates = []*Rate{}
+		for _, rd := range dd.Rates {
+			if rd == nil {
+				return fmt.Errorf("rate is nil")
+			}
+			if rd.Version != 0 {
+				return fmt.Errorf("version in rate of contract : %d", rd.Version)
+			}
+			r := &Rate{}
+			err = r.fromData(rd)
+			if err != nil {
+				return err
+			}
+			if len(r.msgs) != nd.length {
+				return fmt.Errorf("illegal rate messages : %x", r.msgs)
+			}
+			if r.amta+r.amtb != nd.FundAmount() {
+				return fmt.Errorf("illegal rate amount : %d, %d, %d",
+					r.amta, r.amtb, nd.FundAmount())
+			}
+			if dd.Version < 3 && !nd.taproot && r.rsign != nil {
+				return fmt.Errorf("settlement signature of version %d is not adaptor signature", dd.Version)
+			}
+			nd.rates = append(nd.rates, r)
+		}
+		nd.trie, err = newRateTrie(nd.rates, nd.length)
+		if err != nil {
+			return err
+		}
+	}
+	if dd.Frate < -1 || dd.Frate >= len(nd.rates) {
+		return fmt.Errorf("illegal fixed rate index : %d", dd.Frate)
+	}
+	if dd.Frate >= 0 {
+		nd.frate = nd.rates[dd.Frate]
+	}
+	*d = *nd
+	return nil
+}
+
+// toData returns the encoded dataset of Rate without version.
+func (r *Rate) toData() *rateData {
+	rd := &rateData{}
+	rd.Msgs = []hexBytes{}
+	for _, m := range r.msgs {
+		rd.Msgs = append(rd.Msgs, m)
+	}
+	rd.Amta, rd.Amtb = r.amta, r.amtb
+	rd.Key = pubToBytes(r.key)
+	rd.Rsign = r.rsign
+	rd.Msign = intToBytes(r.msign)
+	rd.Txid = hashToStr(r.txid)
+	return rd
+}
+
+// fromData sets the Rate of encoded dataset after checking it.
+// The Rate is not changed on error.
+func (r *Rate) fromData(rd *rateData) error {
+	nr := &Rate{}
+	if len(rd.Msgs) == 0 {
+		return fmt.Errorf("rate messages are empty")
+	}
+	nr.msgs = [][]byte{}
+	for _, m := range rd.Msgs {
+		if m != nil && len(m) == 0 {
+			return fmt.Errorf("rate message is empty")
+		}
+		nr.msgs = append(nr.msgs, m)
+	}
+	if rd.Amta < 0 || rd.Amtb < 0 {
+		return fmt.Errorf("illegal rate amount : %d, %d", rd.Amta, rd.Amtb)
+	}
+	nr.amta, nr.amtb = rd.Amta, rd.Amtb
+	var err error
+	nr.key, err = bytesToPub(rd.Key)
+	if err != nil {
+		return err
+	}
+	nr.rsign = rd.Rsign
+	nr.msign, err = bytesToInt(rd.Msign)
+	if err != nil {
+		return err
+	}
+	nr.txid, err = strToHash(rd.Txid)
+	if err != nil {
+		return err
+	}
+	*r = *nr
+	return nil
+}
+
+func txinsToData(txins []*FundTxIn) []*txinData {
+	if txins == nil {
+		return nil
+	}
+	list := []*txinData{}
+	for _, txin := range txins {
+		td := &txinData{}
+		td.Serial = txin.Serial
+		td.Txid = txin.TxIn.PreviousOutPoint.Hash.String()
+		td.Vout = txin.TxIn.PreviousOutPoint.Index
+		td.Sequence = txin.TxIn.Sequence
+		td.Script = txin.TxIn.SignatureScript
+		if txin.TxIn.Witness != nil {
+			td.Witness = []hexBytes{}
+			for _, w := range txin.TxIn.Witness {
+				td.Witness = append(td.Witness, w)
+			}
+		}
+		list = append(list, td)
+	}
+	return list
+}
+
+func dataToTxins(list []*txinData) ([]*FundTxIn, error) {
+	if list == nil {
+		return nil, nil
+	}
+	txins := []*FundTxIn{}
+	for _, td := range list {
+		if td == nil {
+			return nil, fmt.Errorf("txin is nil")
+		}
+		hash, err := strToHash(td.Txid)
+		if err != nil {
+			return nil, err
+		}
+		if hash == nil {
+			return nil, fmt.Errorf("txid of txin is empty")
+		}
+		txin := wire.NewTxIn(wire.NewOutPoint(hash, td.Vout), td.Script, nil)
+		txin.Sequence = td.Sequence
+		if td.Witness != nil {
+			txin.Witness = wire.TxWitness{}
+			for _, w := range td.Witness {
+				txin.Witness = append(txin.Witness, w)
+			}
+		}
+		txins = append(txins, &FundTxIn{td.Serial, txin})
+	}
+	return txins, nil
+}
+
+func txoutsToData(txouts []*FundTxOut) []*txoutData {
+	if txouts == nil {
+		return nil
+	}
+	list := []*txoutData{}
+	for _, txout := range txouts {
+		list = append(list, &txoutData{txout.Serial, txout.TxOut.Value, txout.TxOut.PkScript})
+	}
+	return list
+}
+
+func dataToTxouts(list []*txoutData) ([]*FundTxOut, error) {
+	if list == nil {
+		return nil, nil
+	}
+	txouts := []*FundTxOut{}
+	for _, td := range list {
+		if td == nil {
+			return nil, fmt.Errorf("txout is nil")
+		}
+		if td.Value < 0 || td.PkScript == nil {
+			return nil, fmt.Errorf("illegal txout : %d, %x", td.Value, td.PkScript)
+		}
+		txouts = append(txouts, &FundTxOut{td.Serial, wire.NewTxOut(td.Value, td.PkScript)})
+	}
+	return txouts, nil
+}
+
+// encode writes the dataset of Dlc.
+// The lists are written as the header and the elements.
+func (dd *dlcData) encode(e *encoder) {
+	e.putUint32(uint32(dd.Version))
+	for _, v := range []int64{dd.Famta, dd.Famtb, dd.Fefee, dd.Sefee,
+		dd.Sfeea, dd.Sfeeb, dd.Ffeea, dd.Ffeeb} {
+		e.putInt64(v)
+	}
+	payers := dd.Payers
+	if payers == nil {
+		payers = &FeePayers{}
+	}
+	e.putString(payers.Fund)
+	e.putString(payers.Settlement)
+	e.putString(payers.Refund)
+	e.putBool(dd.OfferA)
+	e.putBool(dd.IsA)
+	e.putBytes(dd.TempID)
+	e.putUint32(dd.Locktime)
+	e.putBytes(dd.Puba)
+	e.putBytes(dd.Pubb)
+	for _, list := range [][]*txinData{dd.Atxins, dd.Btxins} {
+		e.putHeader(list != nil, len(list))
+		for _, td := range list {
+			td.encode(e)
+		}
+	}
+	for _, list := range [][]*txoutData{dd.Atxouts, dd.Btxouts} {
+		e.putHeader(list != nil, len(list))
+		for _, td := range list {
+			e.putUint64(td.Serial)
+			e.putInt64(td.Value)
+			e.putBytes(td.PkScript)
+		}
+	}
+	e.putUint64(dd.Fserial)
+	e.putBytes(dd.Pscripta)
+	e.putBytes(dd.Pscriptb)
+	e.putBytes(dd.Rsigna)
+	e.putBytes(dd.Rsignb)
+	e.putBytes(dd.Pubo)
+	for _, list := range [][]hexBytes{dd.Okeys, dd.Omsgs, dd.Osigns} {
+		putBytesList(e, list)
+	}
+	e.putHeader(dd.Rates != nil, len(dd.Rates))
+	for _, rd := range dd.Rates {
+		rd.encode(e)
+	}
+	e.putBool(dd.Payout != nil)
+	if dd.Payout != nil {
+		e.putString(dd.Payout.Type)
+		e.putHeader(dd.Payout.Points != nil, len(dd.Payout.Points))
+		for _, p := range dd.Payout.Points {
+			e.putInt64(p.Outcome)
+			e.putInt64(p.Payout)
+		}
+	}
+	e.putHeader(dd.Rounding != nil, len(dd.Rounding))
+	for _, ri := range dd.Rounding {
+		e.putInt64(ri.Begin)
+		e.putInt64(ri.Mod)
+	}
+	e.putInt64(int64(dd.Frate))
+	e.putInt64(int64(dd.Height))
+	e.putInt64(int64(dd.Length))
+	e.putHash(dd.Hash)
+	e.putBool(dd.Taproot)
+	e.putBool(dd.Rollover != nil)
+	if dd.Rollover != nil {
+		e.putUint64(dd.Rollover.Serial)
+		e.putHash(dd.Rollover.Txid)
+		e.putUint32(dd.Rollover.Vout)
+		e.putInt64(dd.Rollover.Amta)
+		e.putInt64(dd.Rollover.Amtb)
+		e.putBool(dd.Rollover.Taproot)
+	}
+	e.putHeader(dd.Otxins != nil, len(dd.Otxins))
+	for _, td := range dd.Otxins {
+		td.encode(e)
+	}
+	e.putHeader(dd.Otxouts != nil, len(dd.Otxouts))
+	for _, td := range dd.Otxouts {
+		e.putUint64(td.Serial)
+		e.putInt64(td.Value)
+		e.putBytes(td.PkScript)
+	}
+}
+
+// decode reads the dataset of Dlc.
+func (dd *dlcData) decode(dec *decoder) {
+	dd.Version = int(dec.getUint32())
+	for _, v := range []*int64{&dd.Famta, &dd.Famtb, &dd.Fefee, &dd.Sefee,
+		&dd.Sfeea, &dd.Sfeeb, &dd.Ffeea, &dd.Ffeeb} {
+		*v = dec.getInt64()
+	}
+	dd.Payers = &FeePayers{}
+	dd.Payers.Fund = dec.getString()
+	dd.Payers.Settlement = dec.getString()
+	dd.Payers.Refund = dec.getString()
+	dd.OfferA = dec.getBool()
+	dd.IsA = dec.getBool()
+	dd.TempID = dec.getBytes()
+	dd.Locktime = dec.getUint32()
+	if dd.Version < 3 {
+		dd.Delay = dec.getUint32()
+	}
+	dd.Puba = dec.getBytes()
+	dd.Pubb = dec.getBytes()
+	for _, list := range []*[]*txinData{&dd.Atxins, &dd.Btxins} {
+		ok, n := dec.getHeader()
+		if !ok {
+			continue
+		}
+		*list = []*txinData{}
+		for ; n > 0 && dec.err == nil; n-- {
+			td := &txinData{}
+			td.decode(dec)
+			*list = append(*list, td)
+		}
+	}
+	for _, list := range []*[]*txoutData{&dd.Atxouts, &dd.Btxouts} {
+		ok, n := dec.getHeader()
+		if !ok {
+			continue
+		}
+		*list = []*txoutData{}
+		for ; n > 0 && dec.err == nil; n-- {
+			td := &txoutData{}
+			td.Serial = dec.getUint64()
+			td.Value = dec.getInt64()
+			td.PkScript = dec.getBytes()
+			*list = append(*list, td)
+		}
+	}
+	dd.Fserial = dec.getUint64()
+	dd.Pscripta = dec.getBytes()
+	dd.Pscriptb = dec.getBytes()
+	dd.Rsigna = dec.getBytes()
+	dd.Rsignb = dec.getBytes()
+	dd.Pubo = dec.getBytes()
+	dd.Okeys = getBytesList(dec)
+	dd.Omsgs = getBytesList(dec)
+	dd.Osigns = getBytesList(dec)
+	if ok, n := dec.getHeader(); ok {
+		dd.Rates = []*rateData{}
+		for ; n > 0 && dec.err == nil; n-- {
+			rd := &rateData{}
+			rd.decode(dec)
+			dd.Rates = append(dd.Rates, rd)
+		}
+	}
+	if dec.getBool() {
+		dd.Payout = &PayoutDescriptor{}
+		dd.Payout.Type = dec.getString()
+		if ok, n := dec.getHeader(); ok {
+			dd.Payout.Points = []PayoutPoint{}
+			for ; n > 0 && dec.err == nil; n-- {
+				p := PayoutPoint{}
+				p.Outcome = dec.getInt64()
+				p.Payout = dec.getInt64()
+				dd.Payout.Points = append(dd.Payout.Points, p)
+			}
+		}
+	}
+	if ok, n := dec.getHeader(); ok {
+		dd.Rounding = []RoundingInterval{}
+		for ; n > 0 && dec.err == nil; n-- {
+			ri := RoundingInterval{}
+			ri.Begin = dec.getInt64()
+			ri.Mod = dec.getInt64()
+			dd.Rounding = append(dd.Rounding, ri)
+		}
+	}
+	dd.Frate = int(dec.getInt64())
+	dd.Height = int(dec.getInt64())
+	dd.Length = int(dec.getInt64())
+	dd.Hash = dec.getHash()
+	if dd.Version >= 2 {
+		dd.Taproot = dec.getBool()
+	}
+	if dd.Version >= 4 && dec.getBool() {
+		dd.Rollover = &Rollover{}
+		dd.Rollover.Serial = dec.getUint64()
+		dd.Rollover.Txid = dec.getHash()
+		dd.Rollover.Vout = dec.getUint32()
+		dd.Rollover.Amta = dec.getInt64()
+		dd.Rollover.Amtb = dec.getInt64()
+		dd.Rollover.Taproot = dec.getBool()
+	}
+	if dd.Version < 5 {
+		return
+	}
+	if ok, n := dec.getHeader(); ok {
+		dd.Otxins = []*txinData{}
+		for ; n > 0 && dec.err == nil; n-- {
+			td := &txinData{}
+			td.decode(dec)
+			dd.Otxins = append(dd.Otxins, td)
+		}
+	}
+	if ok, n := dec.getHeader(); ok {
+		dd.Otxouts = []*txoutData{}
+		for ; n > 0 && dec.err == nil; n-- {
+			td := &txoutData{}
+			td.Serial = dec.getUint64()
+			td.Value = dec.getInt64()
+			td.PkScript = dec.getBytes()
+			dd.Otxouts = append(dd.Otxouts, td)
+		}
+	}
+}
+
+func (td *txinData) encode(e *encoder) {
+	e.putUint64(td.Serial)
+	e.putHash(td.Txid)
+	e.putUint32(td.Vout)
+	e.putUint32(td.Sequence)
+	e.putBytes(td.Script)
+	putBytesList(e, td.Witness)
+}
+
+func (td *txinData) decode(dec *decoder) {
+	td.Serial = dec.getUint64()
+	td.Txid = dec.getHash()
+	td.Vout = dec.getUint32()
+	td.Sequence = dec.getUint32()
+	td.Script = dec.getBytes()
+	td.Witness = getBytesList(dec)
+}
+
+// encode writes the dataset of Rate without version.
+func (rd *rateData) encode(e *encoder) {
+	putBytesList(e, rd.Msgs)
+	e.putInt64(rd.Amta)
+	e.putInt64(rd.Amtb)
+	e.putBytes(rd.Key)
+	e.putBytes(rd.Rsign)
+	e.putBytes(rd.Msign)
+	e.putHash(rd.Txid)
+}
+
+// decode reads the dataset of Rate without version.
+func (rd *rateData) decode(dec *decoder) {
+	rd.Msgs = getBytesList(dec)
+	rd.Amta = dec.getInt64()
+	rd.Amtb = dec.getInt64()
+	rd.Key = dec.getBytes()
+	rd.Rsign = dec.getBytes()
+	rd.Msign = dec.getBytes()
+	rd.Txid = dec.getHash()
+}
+
+// putBytesList writes the header and the bytes of list.
+func putBytesList(e *encoder, list []hexBytes) {
+	e.putHeader(list != nil, len(list))
+	for _, b := range list {
+		e.putBytes(b)
+	}
+}
+
+func getBytesList(dec *decoder) []hexBytes {
+	ok, n := dec.getHeader()
+	if !ok {
+		return nil
+	}
+	list := []hexBytes{}
+	for ; n > 0 && dec.err == nil; n-- {
+		list = append(list, dec.getBytes())
+	}
+	return list
+}
@@ -0,0 +1,318 @@
+package dlc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func testPub(i byte) *btcec.PublicKey {
+	_, pub := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{i}, 32))
+	return pub
+}
+
+func testHash(i byte) *chainhash.Hash {
+	hash := chainhash.DoubleHashH([]byte{i})
+	return &hash
+}
+
+func testTxIn(serial uint64, i byte, witness bool) *FundTxIn {
+	txin := wire.NewTxIn(wire.NewOutPoint(testHash(i), uint32(i)), nil, nil)
+	txin.Sequence = 0xfffffffd
+	if witness {
+		txin.Witness = wire.TxWitness{bytes.Repeat([]byte{i}, 71), testPub(i).SerializeCompressed()}
+	} else {
+		txin.SignatureScript = []byte{0x00, i}
+	}
+	return &FundTxIn{serial, txin}
+}
+
+func testTxOut(serial uint64, i byte) *FundTxOut {
+	pkScript := append([]byte{0x00, 0x14}, bytes.Repeat([]byte{i}, 20)...)
+	return &FundTxOut{serial, wire.NewTxOut(int64(i)*1000, pkScript)}
+}
+
+// testDlc returns a Dlc whose every field is set.
+func testDlc(t *testing.T) *Dlc {
+	d := &Dlc{}
+	d.famta, d.famtb = 60000, 40000
+	d.fefee, d.sefee = 2, 3
+	d.sfeea, d.sfeeb = 300, 200
+	d.ffeea, d.ffeeb = 150, 100
+	d.payers = &FeePayers{PayerBoth, PayerOfferer, PayerAcceptor}
+	d.offerA, d.isA = true, false
+	d.tempID = bytes.Repeat([]byte{0x42}, IDSize)
+	d.locktime = 1144
+	d.puba, d.pubb = testPub(1), testPub(2)
+	d.atxins = []*FundTxIn{testTxIn(10, 10, true), testTxIn(11, 11, false)}
+	d.btxins = []*FundTxIn{testTxIn(12, 12, true)}
+	d.atxouts = []*FundTxOut{testTxOut(20, 20)}
+	d.btxouts = []*FundTxOut{testTxOut(21, 21), testTxOut(22, 22)}
+	d.fserial = 23
+	d.pscripta = testTxOut(0, 30).TxOut.PkScript
+	d.pscriptb = testTxOut(0, 31).TxOut.PkScript
+	d.rsigna, d.rsignb = bytes.Repeat([]byte{0x30}, 71), bytes.Repeat([]byte{0x31}, 70)
+	d.pubo = testPub(3)
+	d.okeys = []*btcec.PublicKey{testPub(4), testPub(5)}
+	d.omsgs = [][]byte{{0x01}, {0x02}}
+	d.osigns = []*big.Int{big.NewInt(7), new(big.Int).Sub(btcec.S256().N, big.NewInt(1))}
+	d.height, d.length = 1000, 2
+	d.hash = testHash(40)
+	payout, err := NewPiecewiseLinear([]PayoutPoint{{0, 0}, {0xffff, d.FundAmount()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.payout = payout
+	d.rounding = []RoundingInterval{{0, 10}, {0x8000, 100}}
+	d.rates = []*Rate{
+		NewRate([][]byte{nil, {0x00}}, 0, d.FundAmount()),
+		NewRate([][]byte{{0x01}, {0x02}}, 35000, 65000),
+		NewRate([][]byte{nil, []byte("cancelled")}, d.famta, d.famtb),
+	}
+	for i, r := range d.rates {
+		r.key = testPub(byte(50 + i))
+		r.rsign = bytes.Repeat([]byte{byte(60 + i)}, 65)
+		r.msign = big.NewInt(int64(70 + i))
+		r.txid = testHash(byte(80 + i))
+	}
+	d.frate = d.rates[1]
+	d.taproot = true
+	d.roll = &Rollover{24, testHash(90).String(), 1, 50000, 45000, true}
+	d.otxins = []*FundTxIn{testTxIn(13, 13, true)}
+	d.otxouts = []*FundTxOut{testTxOut(25, 25)}
+	err = d.checkSerials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// checkDlc checks the decoded Dlc has the fields of the encoded Dlc.
+func checkDlc(t *testing.T, d, nd *Dlc) {
+	t.Helper()
+	if !reflect.DeepEqual(d.toData(), nd.toData()) {
+		a, _ := json.Marshal(d.toData())
+		b, _ := json.Marshal(nd.toData())
+		t.Fatalf("decoded Dlc mismatch :\n%s\n%s", a, b)
+	}
+	if nd.frate != nd.rates[1] {
+		t.Fatalf("fixed rate is not in rates")
+	}
+	if !bytes.Equal(nd.rates[1].rsign, d.rates[1].rsign) ||
+		nd.rates[1].msign.Cmp(d.rates[1].msign) != 0 ||
+		!nd.rates[1].txid.IsEqual(d.rates[1].txid) {
+		t.Fatalf("rate mismatch : %v, %v", nd.rates[1], d.rates[1])
+	}
+	if !reflect.DeepEqual(nd.atxins[0].TxIn, d.atxins[0].TxIn) ||
+		!reflect.DeepEqual(nd.atxins[1].TxIn, d.atxins[1].TxIn) {
+		t.Fatalf("txin mismatch : %v, %v", nd.atxins[0].TxIn, d.atxins[0].TxIn)
+	}
+	if nd.PayoutFunction().Payout(0x8000) != d.PayoutFunction().Payout(0x8000) {
+		t.Fatalf("payout function mismatch")
+	}
+}
+
+func TestDlcBinaryRoundTrip(t *testing.T) {
+	d := testDlc(t)
+	bs, err := d.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nd := &Dlc{}
+	err = nd.UnmarshalBinary(bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDlc(t, d, nd)
+	bs2, _ := nd.MarshalBinary()
+	if !bytes.Equal(bs, bs2) {
+		t.Fatalf("binary encoding is not stable")
+	}
+}
+
+func TestDlcJSONRoundTrip(t *testing.T) {
+	d := testDlc(t)
+	bs, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nd := &Dlc{}
+	err = json.Unmarshal(bs, nd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDlc(t, d, nd)
+	bs2, _ := json.Marshal(nd)
+	if !bytes.Equal(bs, bs2) {
+		t.Fatalf("JSON encoding is not stable")
+	}
+}
+
+func TestRateRoundTrip(t *testing.T) {
+	r := testDlc(t).rates[1]
+	bs, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nr := &Rate{}
+	err = nr.UnmarshalBinary(bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.toData(), nr.toData()) {
+		t.Fatalf("binary rate mismatch : %v, %v", r, nr)
+	}
+	bs, err = json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nr = &Rate{}
+	err = json.Unmarshal(bs, nr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.toData(), nr.toData()) {
+		t.Fatalf("JSON rate mismatch : %v, %v", r, nr)
+	}
+}
+
+// The files of testdata are encoded by the older versions from the Dlc of testDlc
+// without the fields added later.
+// The files before version 3 have the settlement delay 144, which is rejected,
+// and they are decoded after the delay is cleared.
+func TestDecodeOlderVersions(t *testing.T) {
+	for version := 1; version < EncodingVersion; version++ {
+		d := testDlc(t)
+		d.otxins, d.otxouts = nil, nil
+		if version < 4 {
+			d.roll = nil
+		}
+		if version < 2 {
+			d.taproot = false
+			for _, r := range d.rates {
+				r.rsign = nil
+			}
+		}
+		name := filepath.Join("testdata", fmt.Sprintf("dlc-v%d", version))
+		bs, err := ioutil.ReadFile(name + ".hex")
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, err = hex.DecodeString(string(bytes.TrimSpace(bs)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		js, err := ioutil.ReadFile(name + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version < 3 {
+			// locktime 1144 and delay 144 in little endian
+			delay := []byte{0x78, 0x04, 0x00, 0x00, 0x90, 0x00, 0x00, 0x00}
+			if bytes.Count(bs, delay) != 1 || (&Dlc{}).UnmarshalBinary(bs) == nil {
+				t.Fatalf("binary version %d of delay is decoded", version)
+			}
+			bs = bytes.Replace(bs, delay, []byte{0x78, 0x04, 0, 0, 0, 0, 0, 0}, 1)
+			if json.Unmarshal(js, &Dlc{}) == nil {
+				t.Fatalf("JSON version %d of delay is decoded", version)
+			}
+			js = bytes.Replace(js, []byte(`"delay":144`), []byte(`"delay":0`), 1)
+		}
+		nd := &Dlc{}
+		err = nd.UnmarshalBinary(bs)
+		if err != nil {
+			t.Fatalf("binary version %d : %v", version, err)
+		}
+		checkDlc(t, d, nd)
+		nd = &Dlc{}
+		err = json.Unmarshal(js, nd)
+		if err != nil {
+			t.Fatalf("JSON version %d : %v", version, err)
+		}
+		checkDlc(t, d, nd)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	d := testDlc(t)
+	d.otxouts = nil
+	bs, _ := d.MarshalBinary()
+	js, _ := json.Marshal(d)
+	rbs, _ := d.rates[0].MarshalBinary()
+	rjs, _ := json.Marshal(d.rates[0])
+	// the last byte is the header of nil otxouts
+	last := len(bs) - 1
+	if bs[last] != 0x00 {
+		t.Fatalf("last byte : %#x", bs[last])
+	}
+	badFlag := append(append([]byte{}, bs[:last]...), 0x02)
+	bigCount := append(append([]byte{}, bs[:last]...), 0x01, 0xfd, 0xff, 0xff)
+	hugeCount := append(append([]byte{}, bs[:last]...), 0x01, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f)
+	version := func(bs []byte, v byte) []byte {
+		bs = append([]byte{}, bs...)
+		bs[0] = v
+		return bs
+	}
+	field := func(js []byte, old, new string) []byte {
+		return []byte(strings.Replace(string(js), old, new, 1))
+	}
+	binaries := map[string][]byte{
+		"trailing bytes": append(append([]byte{}, bs...), 0x00),
+		"truncated":      bs[:last],
+		"bad flag":       badFlag,
+		"big count":      bigCount,
+		"huge count":     hugeCount,
+		"version 0":      version(bs, 0),
+		"version 6":      version(bs, 6),
+	}
+	for name, b := range binaries {
+		nd := &Dlc{}
+		if nd.UnmarshalBinary(b) == nil {
+			t.Fatalf("binary %s is decoded", name)
+		}
+	}
+	jsons := map[string][]byte{
+		"unknown field": field(js, `"version":5,`, `"version":5,"unknown":1,`),
+		"trailing data": append(append([]byte{}, js...), []byte(`{}`)...),
+		"version 6":     field(js, `"version":5,`, `"version":6,`),
+		"bad hex":       field(js, `"tempid":"42`, `"tempid":"4x`),
+		"delay":         field(js, `"delay":0,`, `"delay":144,`),
+	}
+	for name, b := range jsons {
+		nd := &Dlc{}
+		if json.Unmarshal(b, nd) == nil {
+			t.Fatalf("JSON %s is decoded", name)
+		}
+	}
+	rates := map[string][]byte{
+		"binary trailing bytes": append(append([]byte{}, rbs...), 0x00),
+		"binary version 6":      version(rbs, 6),
+	}
+	for name, b := range rates {
+		nr := &Rate{}
+		if nr.UnmarshalBinary(b) == nil {
+			t.Fatalf("rate %s is decoded", name)
+		}
+	}
+	nr := &Rate{}
+	if json.Unmarshal(field(rjs, `"version":5,`, `"version":5,"x":1,`), nr) == nil {
+		t.Fatalf("rate unknown field is decoded")
+	}
+	// The Dlc is not changed on error.
+	nd := testDlc(t)
+	if nd.UnmarshalBinary(badFlag) == nil || !reflect.DeepEqual(nd.toData(), testDlc(t).toData()) {
+		t.Fatalf("Dlc is changed on error")
+	}
+}
@@ -0,0 +1,77 @@
+// Package dlc project payer.go
+package dlc
+
+import "fmt"
+
+// Fee payers
+const (
+	PayerOfferer  = "offerer"
+	PayerAcceptor = "acceptor"
+	PayerBoth     = "both"
+)
+
+// FeePayers is who pays the fees of fund, settlement and refund transactions.
+type FeePayers struct {
+	Fund       string `json:"fund"`       // payer of fund base fee
+	Settlement string `json:"settlement"` // payer of settlement fee
+	Refund     string `json:"refund"`     // payer of refund fee
+}
+
+// SetFeePayers splits the fees by payers.
+// The fund base fee is for FundBaseWeight at fund estimate fee,
+// and the settlement and refund fees are for their weights at settlement estimate fee.
+// The settlement fee is kept in fund output for the maximum payout script,
+// and the refund fee is paid from it when the payout scripts are known.
+func (d *Dlc) SetFeePayers(payers *FeePayers, offererIsA bool) error {
+	if payers == nil {
+		return fmt.Errorf("fee payers is nil")
+	}
+	ffee := WeightToFee(d.FundBaseWeight(), d.fefee)
+	ffeea, ffeeb, err := splitFee(ffee, payers.Fund, offererIsA)
+	if err != nil {
+		return err
+	}
+	sfee := WeightToFee(d.SettlementTxWeight(maxPayoutScript), d.sefee)
+	sfeea, sfeeb, err := splitFee(sfee, payers.Settlement, offererIsA)
+	if err != nil {
+		return err
+	}
+	rfee := WeightToFee(d.RefundTxWeight(), d.sefee)
+	rfeea, rfeeb, err := splitFee(rfee, payers.Refund, offererIsA)
+	if err != nil {
+		return err
+	}
+	if d.famta+sfeea-rfeea < 0 || d.famtb+sfeeb-rfeeb < 0 {
+		return fmt.Errorf("collateral is short for refund fee : %d, %d", d.famta, d.famtb)
+	}
+	d.ffeea, d.ffeeb = ffeea, ffeeb
+	d.sfeea, d.sfeeb = sfeea, sfeeb
+	d.payers = payers
+	d.offerA = offererIsA
+	return nil
+}
+
+// FeePayers returns the fee payers.
+func (d *Dlc) FeePayers() *FeePayers {
+	return d.payers
+}
+
+// splitFee returns the shares of fee paid by A and B.
+func splitFee(fee int64, payer string, offererIsA bool) (int64, int64, error) {
+	var offerer, acceptor int64
+	switch payer {
+	case PayerOfferer:
+		offerer = fee
+	case PayerAcceptor:
+		acceptor = fee
+	case PayerBoth:
+		offerer = fee - fee/2
+		acceptor = fee / 2
+	default:
+		return 0, 0, fmt.Errorf("unknown fee payer : %s", payer)
+	}
+	if offererIsA {
+		return offerer, acceptor, nil
+	}
+	return acceptor, offerer, nil
+}
@@ -0,0 +1,343 @@
+// Package dlc project payout.go
+package dlc
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// PayoutFunction is the payout curve of contract.
+type PayoutFunction interface {
+	// Payout returns the amount of A (satoshi) for the outcome.
+	Payout(outcome int64) int64
+	// Pieces returns the first outcomes of the pieces in [0, n)
+	// where the payout is monotone until the next piece.
+	Pieces(n int64) []int64
+	// Descriptor returns the descriptor of payout function.
+	Descriptor() *PayoutDescriptor
+}
+
+// PayoutPoint is a point of payout function.
+type PayoutPoint struct {
+	Outcome int64 `json:"outcome"` // outcome
+	Payout  int64 `json:"payout"`  // amount of A (satoshi)
+}
+
+// Payout function types
+const (
+	PayoutLinear     = "linear"
+	PayoutStep       = "step"
+	PayoutPolynomial = "polynomial"
+)
+
+// PayoutDescriptor is the descriptor of payout function.
+type PayoutDescriptor struct {
+	Type   string        `json:"type"`   // linear, step or polynomial
+	Points []PayoutPoint `json:"points"` // points of payout function
+}
+
+// NewPayoutFunction returns a new PayoutFunction of the descriptor.
+func NewPayoutFunction(pd *PayoutDescriptor) (PayoutFunction, error) {
+	if pd == nil {
+		return nil, fmt.Errorf("payout descriptor is nil")
+	}
+	switch pd.Type {
+	case PayoutLinear:
+		return NewPiecewiseLinear(pd.Points)
+	case PayoutStep:
+		return NewStep(pd.Points)
+	case PayoutPolynomial:
+		return NewPolynomial(pd.Points)
+	}
+	return nil, fmt.Errorf("unknown payout type : %s", pd.Type)
+}
+
+// PiecewiseLinear is the payout function which connects points with lines.
+type PiecewiseLinear struct {
+	points []PayoutPoint
+}
+
+// NewPiecewiseLinear returns a new PiecewiseLinear.
+func NewPiecewiseLinear(points []PayoutPoint) (*PiecewiseLinear, error) {
+	err := checkPoints(points, 2)
+	if err != nil {
+		return nil, err
+	}
+	return &PiecewiseLinear{points}, nil
+}
+
+// Payout returns the amount of A (satoshi) for the outcome.
+func (f *PiecewiseLinear) Payout(outcome int64) int64 {
+	ps := f.points
+	if outcome <= ps[0].Outcome {
+		return ps[0].Payout
+	}
+	for i := 1; i < len(ps); i++ {
+		if outcome > ps[i].Outcome {
+			continue
+		}
+		// y = y0 + (y1 - y0) * (x - x0) / (x1 - x0)
+		num := new(big.Int).Mul(
+			big.NewInt(ps[i].Payout-ps[i-1].Payout),
+			big.NewInt(outcome-ps[i-1].Outcome))
+		den := big.NewInt(ps[i].Outcome - ps[i-1].Outcome)
+		return ps[i-1].Payout + roundRat(new(big.Rat).SetFrac(num, den))
+	}
+	return ps[len(ps)-1].Payout
+}
+
+// Pieces returns the first outcomes of the pieces where the payout is monotone.
+// The payout is linear between points.
+func (f *PiecewiseLinear) Pieces(n int64) []int64 {
+	return pointOutcomes(f.points)
+}
+
+// Descriptor returns the descriptor of payout function.
+func (f *PiecewiseLinear) Descriptor() *PayoutDescriptor {
+	return &PayoutDescriptor{PayoutLinear, f.points}
+}
+
+// Step is the payout function which keeps the payout of point until next point.
+type Step struct {
+	points []PayoutPoint
+}
+
+// NewStep returns a new Step.
+func NewStep(points []PayoutPoint) (*Step, error) {
+	err := checkPoints(points, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &Step{points}, nil
+}
+
+// Payout returns the amount of A (satoshi) for the outcome.
+func (f *Step) Payout(outcome int64) int64 {
+	payout := f.points[0].Payout
+	for _, p := range f.points {
+		if outcome < p.Outcome {
+			break
+		}
+		payout = p.Payout
+	}
+	return payout
+}
+
+// Pieces returns the first outcomes of the pieces where the payout is monotone.
+// The payout is constant between points.
+func (f *Step) Pieces(n int64) []int64 {
+	return pointOutcomes(f.points)
+}
+
+// Descriptor returns the descriptor of payout function.
+func (f *Step) Descriptor() *PayoutDescriptor {
+	return &PayoutDescriptor{PayoutStep, f.points}
+}
+
+// Polynomial is the payout function which is the polynomial through points.
+type Polynomial struct {
+	points []PayoutPoint
+}
+
+// NewPolynomial returns a new Polynomial.
+func NewPolynomial(points []PayoutPoint) (*Polynomial, error) {
+	err := checkPoints(points, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &Polynomial{points}, nil
+}
+
+// Payout returns the amount of A (satoshi) for the outcome.
+func (f *Polynomial) Payout(outcome int64) int64 {
+	// Lagrange interpolation
+	// y = sum(yi * prod((x - xj) / (xi - xj)))
+	y := new(big.Rat)
+	for i, pi := range f.points {
+		l := new(big.Rat).SetInt64(pi.Payout)
+		for j, pj := range f.points {
+			if i == j {
+				continue
+			}
+			l.Mul(l, big.NewRat(outcome-pj.Outcome, pi.Outcome-pj.Outcome))
+		}
+		y.Add(y, l)
+	}
+	return roundRat(y)
+}
+
+// Pieces returns the first outcomes of the pieces where the payout is monotone.
+// The pieces are split where the derivative changes the sign.
+func (f *Polynomial) Pieces(n int64) []int64 {
+	return monotonePieces(f.coefficients(), 0, n-1)
+}
+
+// coefficients returns the coefficients of the polynomial from the constant term.
+func (f *Polynomial) coefficients() []*big.Rat {
+	cs := make([]*big.Rat, len(f.points))
+	for i := range cs {
+		cs[i] = new(big.Rat)
+	}
+	for i, pi := range f.points {
+		// l = yi * prod((x - xj) / (xi - xj))
+		l := []*big.Rat{new(big.Rat).SetInt64(pi.Payout)}
+		for j, pj := range f.points {
+			if i == j {
+				continue
+			}
+			den := big.NewRat(1, pi.Outcome-pj.Outcome)
+			next := make([]*big.Rat, len(l)+1)
+			next[0] = new(big.Rat)
+			for k, c := range l {
+				c = new(big.Rat).Mul(c, den)
+				next[k+1] = c
+				next[k].Sub(next[k], new(big.Rat).Mul(c, big.NewRat(pj.Outcome, 1)))
+			}
+			l = next
+		}
+		for k, c := range l {
+			cs[k].Add(cs[k], c)
+		}
+	}
+	return cs
+}
+
+// Descriptor returns the descriptor of payout function.
+func (f *Polynomial) Descriptor() *PayoutDescriptor {
+	return &PayoutDescriptor{PayoutPolynomial, f.points}
+}
+
+// roundRat returns the integer nearest to r, and half is rounded up.
+func roundRat(r *big.Rat) int64 {
+	// floor((2*num + den) / (2*den))
+	num := new(big.Int).Add(new(big.Int).Lsh(r.Num(), 1), r.Denom())
+	den := new(big.Int).Lsh(r.Denom(), 1)
+	return new(big.Int).Div(num, den).Int64()
+}
+
+// RoundingInterval rounds the payouts from the outcome Begin until the next interval.
+type RoundingInterval struct {
+	Begin int64 `json:"begin"` // first outcome
+	Mod   int64 `json:"mod"`   // payout is rounded to a multiple of Mod (satoshi)
+}
+
+// roundPayout returns the payout rounded by the interval of the outcome.
+func roundPayout(payout, outcome int64, ris []RoundingInterval) int64 {
+	mod := int64(1)
+	for _, ri := range ris {
+		if outcome < ri.Begin {
+			break
+		}
+		mod = ri.Mod
+	}
+	// round half up to a multiple of mod
+	r := payout % mod
+	if r < 0 {
+		r += mod
+	}
+	if 2*r >= mod {
+		return payout - r + mod
+	}
+	return payout - r
+}
+
+func checkRoundingIntervals(ris []RoundingInterval) error {
+	for i, ri := range ris {
+		if ri.Mod < 1 {
+			return fmt.Errorf("rounding mod is less than 1 : %d", ri.Mod)
+		}
+		if i > 0 && ris[i-1].Begin >= ri.Begin {
+			return fmt.Errorf("rounding intervals are not ascending : %d, %d",
+				ris[i-1].Begin, ri.Begin)
+		}
+	}
+	return nil
+}
+
+// QuarterPayout returns the original payout function of the game.
+// The first quarter is won low and all will be paid low,
+// the second and third quarters are paid linearly
+// and the last quarter is won high and all will be paid high.
+func QuarterPayout(amount int64, length int) PayoutFunction {
+	q := outcomes(length) / 4
+	f, _ := NewPiecewiseLinear([]PayoutPoint{
+		{0, 0},
+		{q - 1, 0},
+		{3 * q, amount},
+		{4*q - 1, amount},
+	})
+	return f
+}
+
+// pointOutcomes returns the outcomes of points.
+func pointOutcomes(points []PayoutPoint) []int64 {
+	outcomes := []int64{}
+	for _, p := range points {
+		outcomes = append(outcomes, p.Outcome)
+	}
+	return outcomes
+}
+
+// monotonePieces returns the first outcomes of the pieces in [lo, hi]
+// where the polynomial of coefficients cs is monotone.
+func monotonePieces(cs []*big.Rat, lo, hi int64) []int64 {
+	if len(cs) <= 2 || lo >= hi {
+		return nil
+	}
+	// derivative
+	ds := []*big.Rat{}
+	for k := 1; k < len(cs); k++ {
+		ds = append(ds, new(big.Rat).Mul(cs[k], big.NewRat(int64(k), 1)))
+	}
+	// The derivative is monotone in its own pieces,
+	// so the sign of the derivative changes at most once in a piece.
+	pieces := []int64{}
+	begins := append([]int64{lo}, monotonePieces(ds, lo, hi)...)
+	for i, begin := range begins {
+		end := hi
+		if i+1 < len(begins) {
+			end = begins[i+1] - 1
+		}
+		pieces = append(pieces, begin)
+		sign := evalPolynomial(ds, begin).Sign()
+		if sign == 0 || evalPolynomial(ds, end).Sign() == sign {
+			continue
+		}
+		// the first outcome where the sign is changed
+		l, h := begin+1, end
+		for l < h {
+			m := l + (h-l)/2
+			if evalPolynomial(ds, m).Sign() != sign {
+				h = m
+			} else {
+				l = m + 1
+			}
+		}
+		pieces = append(pieces, l)
+	}
+	return pieces[1:]
+}
+
+// evalPolynomial returns the value of the polynomial of coefficients cs at x.
+func evalPolynomial(cs []*big.Rat, x int64) *big.Rat {
+	y := new(big.Rat)
+	rx := new(big.Rat).SetInt64(x)
+	for k := len(cs) - 1; k >= 0; k-- {
+		y.Mul(y, rx)
+		y.Add(y, cs[k])
+	}
+	return y
+}
+
+func checkPoints(points []PayoutPoint, min int) error {
+	if len(points) < min {
+		return fmt.Errorf("payout points are too few : %d", len(points))
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i-1].Outcome >= points[i].Outcome {
+			return fmt.Errorf("payout points are not ascending : %d, %d",
+				points[i-1].Outcome, points[i].Outcome)
+		}
+	}
+	return nil
+}
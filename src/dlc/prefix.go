@@ -0,0 +1,132 @@
+// Package dlc project prefix.go
+package dlc
+
+import (
+	"sort"
+)
+
+// DigitBase is the base of the digits signed by oracle.
+const DigitBase = 0x100
+
+// outcomes returns the number of outcomes for the digits.
+func outcomes(ndigits int) int64 {
+	n := int64(1)
+	for i := 0; i < ndigits; i++ {
+		n *= DigitBase
+	}
+	return n
+}
+
+// interval is the outcomes from begin to end with the same payout.
+type interval struct {
+	begin  int64 // first outcome
+	end    int64 // last outcome
+	payout int64 // amount of A (satoshi)
+}
+
+// payoutIntervals returns the intervals of the same payout for n outcomes.
+// The payouts are rounded by the rounding intervals.
+func payoutIntervals(f PayoutFunction, n, amount int64, ris []RoundingInterval) []*interval {
+	payout := func(x int64) int64 {
+		p := roundPayout(f.Payout(x), x, ris)
+		if p < 0 {
+			return 0
+		} else if p > amount {
+			return amount
+		}
+		return p
+	}
+	// The rounded payout is monotone in a piece of the payout function
+	// and a rounding interval, so the outcomes of the same payout are
+	// found by searching the end of them instead of every outcome.
+	begins := []int64{0}
+	for _, x := range f.Pieces(n) {
+		begins = append(begins, x)
+	}
+	for _, ri := range ris {
+		begins = append(begins, ri.Begin)
+	}
+	sort.Slice(begins, func(i, j int) bool { return begins[i] < begins[j] })
+	ivs := []*interval{}
+	var iv *interval
+	for i, begin := range begins {
+		end := n - 1
+		if i+1 < len(begins) && begins[i+1] <= end {
+			end = begins[i+1] - 1
+		}
+		if begin < 0 || begin >= n || begin > end {
+			continue
+		}
+		for x := begin; x <= end; {
+			p := payout(x)
+			last := searchEnd(x, end, func(y int64) bool { return payout(y) == p })
+			if iv != nil && iv.payout == p {
+				iv.end = last
+			} else {
+				iv = &interval{x, last, p}
+				ivs = append(ivs, iv)
+			}
+			x = last + 1
+		}
+	}
+	return ivs
+}
+
+// searchEnd returns the last outcome in [begin, end] where same is true.
+// same must be true at begin and not true after the first false.
+func searchEnd(begin, end int64, same func(int64) bool) int64 {
+	// exponential search from begin and binary search in the last step
+	lo, step := begin, int64(1)
+	for lo+step <= end && same(lo+step) {
+		lo += step
+		step *= 2
+	}
+	hi := lo + step - 1
+	if hi > end {
+		hi = end
+	}
+	for lo < hi {
+		m := lo + (hi-lo+1)/2
+		if same(m) {
+			lo = m
+		} else {
+			hi = m - 1
+		}
+	}
+	return lo
+}
+
+// coverPrefixes returns the minimum digit prefixes covering outcomes from begin to end.
+// A prefix is the most significant digits first, and the rest digits are any.
+func coverPrefixes(begin, end, base int64, ndigits int) [][]int64 {
+	prefixes := [][]int64{}
+	for begin <= end {
+		// The largest block which begins with begin and ends until end.
+		size := int64(1)
+		k := 0
+		for k < ndigits && begin%(size*base) == 0 && begin+size*base-1 <= end {
+			size *= base
+			k++
+		}
+		prefix := make([]int64, ndigits-k)
+		tmp := begin / size
+		for i := len(prefix) - 1; i >= 0; i-- {
+			prefix[i] = tmp % base
+			tmp /= base
+		}
+		prefixes = append(prefixes, prefix)
+		begin += size
+	}
+	return prefixes
+}
+
+// prefixMessages returns the messages of the prefix.
+// The message of index ndigits-1 is the most significant digit,
+// and the messages of the digits out of prefix are nil.
+func prefixMessages(prefix []int64, ndigits int) [][]byte {
+	msgs := make([][]byte, ndigits)
+	for i, digit := range prefix {
+		msgs[ndigits-1-i] = []byte{byte(digit)}
+	}
+	return msgs
+}
@@ -0,0 +1,145 @@
+package dlc
+
+import (
+	"testing"
+)
+
+// enumerateIntervals returns the intervals by evaluating every outcome.
+func enumerateIntervals(f PayoutFunction, n, amount int64, ris []RoundingInterval) []*interval {
+	ivs := []*interval{}
+	var iv *interval
+	for x := int64(0); x < n; x++ {
+		payout := roundPayout(f.Payout(x), x, ris)
+		if payout < 0 {
+			payout = 0
+		} else if payout > amount {
+			payout = amount
+		}
+		if iv != nil && iv.payout == payout {
+			iv.end = x
+			continue
+		}
+		iv = &interval{x, x, payout}
+		ivs = append(ivs, iv)
+	}
+	return ivs
+}
+
+func testPayoutFunctions(t *testing.T, n, amount int64) map[string]PayoutFunction {
+	fs := map[string]PayoutFunction{}
+	fs["quarter"] = QuarterPayout(amount, 2)
+	var err error
+	fs["linear"], err = NewPiecewiseLinear([]PayoutPoint{
+		{-10, amount / 2}, {n / 3, amount + 500}, {n / 2, -300}, {n + 10, amount / 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs["step"], err = NewStep([]PayoutPoint{{n / 5, 100}, {n / 4, 100}, {n / 2, amount}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// not monotone, and out of amount
+	fs["polynomial"], err = NewPolynomial([]PayoutPoint{
+		{0, 0}, {n / 4, amount}, {n / 2, amount / 3}, {3 * n / 4, amount + 100}, {n - 1, 0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs["constant"], err = NewPolynomial([]PayoutPoint{{7, amount / 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fs
+}
+
+func TestPayoutIntervals(t *testing.T) {
+	amount := int64(10000)
+	risList := [][]RoundingInterval{
+		nil,
+		{{0, 100}},
+		{{0, 1}, {100, 500}, {200, 1}, {40000, 33}},
+	}
+	for _, n := range []int64{outcomes(1), 5000} {
+		for name, f := range testPayoutFunctions(t, n, amount) {
+			for i, ris := range risList {
+				got := payoutIntervals(f, n, amount, ris)
+				want := enumerateIntervals(f, n, amount, ris)
+				if len(got) != len(want) {
+					t.Fatalf("%s %d %d : size %d, %d", name, n, i, len(got), len(want))
+				}
+				for k := range want {
+					if *got[k] != *want[k] {
+						t.Fatalf("%s %d %d : interval %d %v, %v",
+							name, n, i, k, *got[k], *want[k])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPayoutIntervalsMaxLength(t *testing.T) {
+	amount := int64(100000000)
+	n := outcomes(MaxGameLength)
+	f := QuarterPayout(amount, MaxGameLength)
+	ris := []RoundingInterval{{0, 100000}}
+	ivs := payoutIntervals(f, n, amount, ris)
+	next := int64(0)
+	for i, iv := range ivs {
+		if iv.begin != next || iv.end < iv.begin {
+			t.Fatalf("interval %d is not continuous : %v", i, *iv)
+		}
+		if i > 0 && ivs[i-1].payout == iv.payout {
+			t.Fatalf("interval %d is not merged : %v", i, *iv)
+		}
+		for _, x := range []int64{iv.begin, iv.end} {
+			if p := roundPayout(f.Payout(x), x, ris); p != iv.payout {
+				t.Fatalf("payout of %d : %d, %d", x, p, iv.payout)
+			}
+		}
+		next = iv.end + 1
+	}
+	if next != n {
+		t.Fatalf("intervals end : %d, %d", next, n)
+	}
+	if len(ivs) != 1001 {
+		t.Fatalf("intervals size : %d", len(ivs))
+	}
+}
+
+func TestCoverPrefixes(t *testing.T) {
+	base := int64(4)
+	ndigits := 3
+	n := int64(64)
+	for begin := int64(0); begin < n; begin++ {
+		for end := begin; end < n; end++ {
+			covered := make([]int, n)
+			for _, prefix := range coverPrefixes(begin, end, base, ndigits) {
+				for x := int64(0); x < n; x++ {
+					v := x
+					for i := len(prefix); i < ndigits; i++ {
+						v /= base
+					}
+					match := true
+					for i := len(prefix) - 1; i >= 0; i-- {
+						if v%base != prefix[i] {
+							match = false
+						}
+						v /= base
+					}
+					if match {
+						covered[x]++
+					}
+				}
+			}
+			for x := int64(0); x < n; x++ {
+				in := 0
+				if begin <= x && x <= end {
+					in = 1
+				}
+				if covered[x] != in {
+					t.Fatalf("cover %d-%d : outcome %d covered %d", begin, end, x, covered[x])
+				}
+			}
+		}
+	}
+}
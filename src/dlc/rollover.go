@@ -0,0 +1,205 @@
+// Package dlc project rollover.go
+package dlc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Rollover is the fund output of the previous contract spent by the fund transaction.
+// The amounts of A and B from the previous fund output are paid into the fund output
+// in place of their inputs, and the excess is returned by their change outputs.
+type Rollover struct {
+	Serial  uint64 `json:"serial"`  // serial id of txin
+	Txid    string `json:"txid"`    // txid of previous fund transaction
+	Vout    uint32 `json:"vout"`    // index of previous fund output
+	Amta    int64  `json:"amta"`    // amount of A from previous fund output (satoshi)
+	Amtb    int64  `json:"amtb"`    // amount of B from previous fund output (satoshi)
+	Taproot bool   `json:"taproot"` // is previous fund output taproot?
+}
+
+// SetRollover sets the previous fund output spent by the fund transaction.
+// The fees are split again by the fee payers.
+func (d *Dlc) SetRollover(roll *Rollover) error {
+	_, err := roll.txIn()
+	if err != nil {
+		return err
+	}
+	d.roll = roll
+	err = d.checkSerials()
+	if err != nil {
+		d.roll = nil
+		return err
+	}
+	if d.payers == nil {
+		return nil
+	}
+	return d.SetFeePayers(d.payers, d.offerA)
+}
+
+// Rollover returns the previous fund output spent by the fund transaction, or nil.
+func (d *Dlc) Rollover() *Rollover {
+	return d.roll
+}
+
+// RolloverIndex returns the txin index of previous fund output in the fund transaction,
+// or -1 if it is not rollover.
+func (d *Dlc) RolloverIndex() int {
+	if d.roll == nil {
+		return -1
+	}
+	idx := 0
+	for _, txin := range append(d.ownTxIns(), d.otxins...) {
+		if txin.Serial < d.roll.Serial {
+			idx++
+		}
+	}
+	return idx
+}
+
+// rolloverAmount returns the amount of A or B from previous fund output.
+func (d *Dlc) rolloverAmount(isA bool) int64 {
+	if d.roll == nil {
+		return 0
+	}
+	if isA {
+		return d.roll.Amta
+	}
+	return d.roll.Amtb
+}
+
+// rolloverInputWeight returns the weight of txin spending previous fund output.
+func (d *Dlc) rolloverInputWeight() int64 {
+	if d.roll == nil {
+		return 0
+	}
+	return fundTxInWeight(d.roll.Taproot)
+}
+
+// txIn returns the fund txin spending previous fund output.
+func (roll *Rollover) txIn() (*FundTxIn, error) {
+	if roll == nil {
+		return nil, fmt.Errorf("rollover is nil")
+	}
+	if roll.Amta < 0 || roll.Amtb < 0 || roll.Amta+roll.Amtb <= 0 {
+		return nil, fmt.Errorf("illegal rollover amounts : %d, %d", roll.Amta, roll.Amtb)
+	}
+	txid, err := chainhash.NewHashFromStr(roll.Txid)
+	if err != nil {
+		return nil, err
+	}
+	txin := wire.NewTxIn(wire.NewOutPoint(txid, roll.Vout), nil, nil)
+	return &FundTxIn{roll.Serial, txin}, nil
+}
+
+// NewRollover returns the rollover spending own fund output into the next contract,
+// where amta and amtb are the amounts of A and B of the next contract.
+func (d *Dlc) NewRollover(amta, amtb int64) (*Rollover, error) {
+	err := d.CheckRolloverAmounts(amta, amtb)
+	if err != nil {
+		return nil, err
+	}
+	roll := &Rollover{}
+	roll.Serial = NewSerialID()
+	roll.Txid = d.FundTx().TxHash().String()
+	roll.Vout = d.FundVout()
+	roll.Amta, roll.Amtb = amta, amtb
+	roll.Taproot = d.taproot
+	return roll, nil
+}
+
+// CheckRollover checks the rollover spends own fund output.
+func (d *Dlc) CheckRollover(roll *Rollover) error {
+	if roll == nil {
+		return fmt.Errorf("rollover is nil")
+	}
+	txid := d.FundTx().TxHash()
+	if roll.Txid != txid.String() || roll.Vout != d.FundVout() {
+		return fmt.Errorf("rollover does not spend fund output : %s:%d", roll.Txid, roll.Vout)
+	}
+	if roll.Taproot != d.taproot {
+		return fmt.Errorf("rollover mode mismatch : %v", roll.Taproot)
+	}
+	return d.CheckRolloverAmounts(roll.Amta, roll.Amtb)
+}
+
+// CheckRolloverAmounts checks the amounts split the fund output.
+func (d *Dlc) CheckRolloverAmounts(amta, amtb int64) error {
+	if amta < 0 || amtb < 0 || amta+amtb != d.FundAmount()+d.SettlementFee() {
+		return fmt.Errorf("rollover amounts are not the fund output : %d, %d, %d",
+			amta, amtb, d.FundAmount()+d.SettlementFee())
+	}
+	return nil
+}
+
+// RolloverShare returns the amount of A or B from own fund output in rollover,
+// which is the payout of the fixed rate, or the collateral before the rate is fixed,
+// with the share of settlement fee.
+func (d *Dlc) RolloverShare(isA bool) int64 {
+	amt := d.Collateral(isA)
+	if d.frate != nil {
+		amt = d.frate.Amount(isA)
+	}
+	if isA {
+		return amt + d.sfeea
+	}
+	return amt + d.sfeeb
+}
+
+// CheckRolloverShare checks the amount of A or B in rollover is not less than the share.
+func (d *Dlc) CheckRolloverShare(roll *Rollover, isA bool) error {
+	amt := roll.Amtb
+	if isA {
+		amt = roll.Amta
+	}
+	if amt < d.RolloverShare(isA) {
+		return fmt.Errorf("rollover amount of %s is short : %d, %d",
+			partyName(isA), amt, d.RolloverShare(isA))
+	}
+	return nil
+}
+
+// RolloverSigHash returns the signature hash of tx spending fund output in rollover.
+// prevOuts are the outputs spent by all txins of tx, which are required in taproot mode.
+func (d *Dlc) RolloverSigHash(tx *wire.MsgTx, prevOuts []*wire.TxOut) ([]byte, error) {
+	idx, err := d.rolloverTxInIndex(tx)
+	if err != nil {
+		return nil, err
+	}
+	if prevOuts == nil && !d.taproot {
+		prevOuts = make([]*wire.TxOut, len(tx.TxIn))
+		prevOuts[idx] = d.fundPrevOut()
+	}
+	if len(prevOuts) != len(tx.TxIn) {
+		return nil, fmt.Errorf("prevouts mismatch : %d, %d", len(prevOuts), len(tx.TxIn))
+	}
+	prev, own := prevOuts[idx], d.fundPrevOut()
+	if prev == nil || prev.Value != own.Value || string(prev.PkScript) != string(own.PkScript) {
+		return nil, fmt.Errorf("prevout is not fund output : %+v", prev)
+	}
+	return d.fundSigHash(tx, idx, prevOuts, false)
+}
+
+// VerifyRolloverTx verifies the signature of tx spending fund output in rollover.
+func (d *Dlc) VerifyRolloverTx(tx *wire.MsgTx, prevOuts []*wire.TxOut,
+	sign []byte, pub *btcec.PublicKey) error {
+	hash, err := d.RolloverSigHash(tx, prevOuts)
+	if err != nil {
+		return err
+	}
+	return d.verifySignHash(hash, sign, pub)
+}
+
+// rolloverTxInIndex returns the txin index of tx spending fund output.
+func (d *Dlc) rolloverTxInIndex(tx *wire.MsgTx) (int, error) {
+	op := wire.OutPoint{Hash: d.FundTx().TxHash(), Index: d.FundVout()}
+	for idx, txin := range tx.TxIn {
+		if txin.PreviousOutPoint == op {
+			return idx, nil
+		}
+	}
+	return -1, fmt.Errorf("fund output is not spent : %v", op)
+}
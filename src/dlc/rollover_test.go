@@ -0,0 +1,210 @@
+package dlc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// testFundedDlc returns a Dlc of A whose fund transaction is fixed by the keys i and i+1.
+func testFundedDlc(t *testing.T, famta, famtb int64, i byte) *Dlc {
+	t.Helper()
+	d, err := NewDlc(famta, famtb, 2, 3, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetPublicKey(testPub(i), true)
+	d.SetPublicKey(testPub(i+1), false)
+	d.SetTemporaryID(bytes.Repeat([]byte{i}, IDSize))
+	d.SetFundSerial(uint64(i) * 10)
+	err = d.SetTxInsAndTxOuts([]*FundTxIn{testTxIn(uint64(i)*10+1, i, true)}, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.SetTxInsAndTxOuts([]*FundTxIn{testTxIn(uint64(i)*10+2, i+1, true)}, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestRolloverAmounts(t *testing.T) {
+	prev := testFundedDlc(t, 60000, 40000, 1)
+	total := prev.FundAmount() + prev.SettlementFee()
+	amta, amtb := prev.RolloverShare(true), prev.RolloverShare(false)
+	if amta+amtb != total {
+		t.Fatalf("shares are not the fund output : %d, %d, %d", amta, amtb, total)
+	}
+	roll, err := prev.NewRollover(amta, amtb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = prev.CheckRollover(roll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, isA := range []bool{true, false} {
+		err = prev.CheckRolloverShare(roll, isA)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if prev.CheckRolloverAmounts(amta+1, amtb) == nil {
+		t.Fatalf("amounts over the fund output are accepted")
+	}
+	if prev.CheckRolloverAmounts(-1, total+1) == nil {
+		t.Fatalf("negative amount is accepted")
+	}
+	moved := *roll
+	moved.Amta, moved.Amtb = amta+1, amtb-1
+	if prev.CheckRollover(&moved) != nil || prev.CheckRolloverShare(&moved, false) == nil {
+		t.Fatalf("short share of B is accepted")
+	}
+	other := *roll
+	other.Vout++
+	if prev.CheckRollover(&other) == nil {
+		t.Fatalf("rollover of another output is accepted")
+	}
+	other = *roll
+	other.Taproot = !roll.Taproot
+	if prev.CheckRollover(&other) == nil {
+		t.Fatalf("rollover of another mode is accepted")
+	}
+	// The payout of the fixed rate is the share.
+	prev.frate = NewRate([][]byte{{0x01}}, prev.FundAmount(), 0)
+	if prev.RolloverShare(false) != prev.sfeeb || prev.CheckRolloverShare(&moved, false) != nil {
+		t.Fatalf("share of fixed rate : %d", prev.RolloverShare(false))
+	}
+	if prev.CheckRolloverShare(roll, true) == nil {
+		t.Fatalf("short share of A is accepted")
+	}
+}
+
+func TestSetRollover(t *testing.T) {
+	prev := testFundedDlc(t, 60000, 40000, 1)
+	roll, err := prev.NewRollover(prev.RolloverShare(true), prev.RolloverShare(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := testFundedDlc(t, 30000, 70000, 3)
+	before := next.FundTxAmount(true) + next.FundTxAmount(false)
+	dup := *roll
+	dup.Serial = 31
+	if next.SetRollover(&dup) == nil || next.Rollover() != nil {
+		t.Fatalf("rollover of duplicate serial is set")
+	}
+	if next.SetRollover(&Rollover{Txid: roll.Txid, Amta: -1, Amtb: 1}) == nil {
+		t.Fatalf("rollover of negative amount is set")
+	}
+	err = next.SetRollover(roll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.Rollover() != roll || next.RolloverIndex() < 0 {
+		t.Fatalf("rollover is not set : %d", next.RolloverIndex())
+	}
+	tx := next.FundTx()
+	op := tx.TxIn[next.RolloverIndex()].PreviousOutPoint
+	if op.Hash != prev.FundTx().TxHash() || op.Index != prev.FundVout() {
+		t.Fatalf("previous fund output is not spent : %v", op)
+	}
+	// The previous fund output pays the amounts of A and B with the weight of its txin.
+	after := next.FundTxAmount(true) + next.FundTxAmount(false)
+	if before-after >= roll.Amta+roll.Amtb || before-after < roll.Amta+roll.Amtb-1000 {
+		t.Fatalf("fund tx amounts : %d, %d, %d", before, after, roll.Amta+roll.Amtb)
+	}
+}
+
+func TestRolloverWithoutInputs(t *testing.T) {
+	prev := testFundedDlc(t, 60000, 40000, 1)
+	roll, err := prev.NewRollover(prev.RolloverShare(true), prev.RolloverShare(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := testFundedDlc(t, 30000, 70000, 3)
+	err = next.SetRollover(roll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A is paid back by the change and adds no txins.
+	if next.FundTxAmount(true) > 0 {
+		t.Fatalf("fund tx amount of A : %d", next.FundTxAmount(true))
+	}
+	err = next.SetTxInsAndTxOuts(nil, []*FundTxOut{testTxOut(35, 20)}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	es := ValidationErrors{}
+	next.validateInputs(&es, true)
+	if len(es) != 0 {
+		t.Fatal(es)
+	}
+	if next.ContractID() == nil {
+		t.Fatalf("contract id without txins of A")
+	}
+	// Without txins of B, the previous fund output is the only txin.
+	err = next.SetTxInsAndTxOuts(nil, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.ContractID() == nil || len(next.FundTx().TxIn) != 1 {
+		t.Fatalf("contract id with only rollover txin")
+	}
+	es = ValidationErrors{}
+	next.validateInputs(&es, true)
+	if !es.Has(InvalidInputs) {
+		t.Fatalf("missing txins of B are accepted")
+	}
+	next.roll = nil
+	if next.ContractID() != nil {
+		t.Fatalf("contract id without txins")
+	}
+	es = ValidationErrors{}
+	next.validateInputs(&es, true)
+	if !es.Has(InvalidInputs) {
+		t.Fatalf("fund tx without txins is accepted")
+	}
+}
+
+func TestRolloverSigHash(t *testing.T) {
+	prev := testFundedDlc(t, 60000, 40000, 1)
+	roll, err := prev.NewRollover(prev.RolloverShare(true), prev.RolloverShare(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := testFundedDlc(t, 30000, 70000, 3)
+	err = next.SetRollover(roll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := next.FundTx()
+	hash, err := prev.RolloverSigHash(tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pri, pub := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{1}, 32))
+	sig, err := pri.Sign(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = prev.VerifyRolloverTx(tx, nil, sig.Serialize(), pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev.VerifyRolloverTx(tx, nil, sig.Serialize(), testPub(2)) == nil {
+		t.Fatalf("signature is verified by another key")
+	}
+	prevOuts := make([]*wire.TxOut, len(tx.TxIn))
+	prevOuts[next.RolloverIndex()] = wire.NewTxOut(roll.Amta+roll.Amtb-1, prev.fundPkScript())
+	if _, err = prev.RolloverSigHash(tx, prevOuts); err == nil {
+		t.Fatalf("sighash of another prevout")
+	}
+	if _, err = prev.RolloverSigHash(tx, prevOuts[1:]); err == nil {
+		t.Fatalf("sighash without all prevouts")
+	}
+	if _, err = prev.RolloverSigHash(prev.FundTx(), nil); err == nil {
+		t.Fatalf("sighash of tx not spending fund output")
+	}
+}
@@ -0,0 +1,110 @@
+// Package dlc project serial.go
+package dlc
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// FundTxIn is the txin of fund transaction with serial id.
+type FundTxIn struct {
+	Serial uint64     // serial id decided by owner
+	TxIn   *wire.TxIn // txin
+}
+
+// FundTxOut is the change txout of fund transaction with serial id.
+type FundTxOut struct {
+	Serial uint64      // serial id decided by owner
+	TxOut  *wire.TxOut // txout
+}
+
+// NewSerialID returns a new random serial id.
+func NewSerialID() uint64 {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return binary.LittleEndian.Uint64(b)
+}
+
+// SetFundSerial sets the serial id of fund output.
+func (d *Dlc) SetFundSerial(serial uint64) {
+	d.fserial = serial
+}
+
+// FundSerial returns the serial id of fund output.
+func (d *Dlc) FundSerial() uint64 {
+	return d.fserial
+}
+
+// SetTxInsAndTxOuts sets txins and change txouts of A or B.
+func (d *Dlc) SetTxInsAndTxOuts(txins []*FundTxIn, txouts []*FundTxOut, isA bool) error {
+	if isA {
+		d.atxins, d.atxouts = txins, txouts
+	} else {
+		d.btxins, d.btxouts = txins, txouts
+	}
+	return d.checkSerials()
+}
+
+// FundTxIns returns the txins of A or B in the order of fund transaction.
+func (d *Dlc) FundTxIns(isA bool) []*wire.TxIn {
+	txins := d.btxins
+	if isA {
+		txins = d.atxins
+	}
+	sorted := sortTxIns(txins)
+	list := []*wire.TxIn{}
+	for _, txin := range sorted {
+		list = append(list, txin.TxIn)
+	}
+	return list
+}
+
+// FundVout returns the output index of fund output.
+func (d *Dlc) FundVout() uint32 {
+	vout := uint32(0)
+	for _, txout := range append(d.ownTxOuts(), d.otxouts...) {
+		if txout.Serial < d.fserial {
+			vout++
+		}
+	}
+	return vout
+}
+
+// checkSerials checks the serial ids are unique in txins and txouts.
+func (d *Dlc) checkSerials() error {
+	ins := map[uint64]bool{}
+	for _, txin := range append(d.ownTxIns(), d.otxins...) {
+		if ins[txin.Serial] {
+			return fmt.Errorf("duplicate serial id of txin : %d", txin.Serial)
+		}
+		ins[txin.Serial] = true
+	}
+	outs := map[uint64]bool{d.fserial: true}
+	for _, txout := range append(d.ownTxOuts(), d.otxouts...) {
+		if outs[txout.Serial] {
+			return fmt.Errorf("duplicate serial id of txout : %d", txout.Serial)
+		}
+		outs[txout.Serial] = true
+	}
+	return nil
+}
+
+func sortTxIns(txins []*FundTxIn) []*FundTxIn {
+	sorted := append([]*FundTxIn{}, txins...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Serial < sorted[j].Serial
+	})
+	return sorted
+}
+
+func sortTxOuts(txouts []*FundTxOut) []*FundTxOut {
+	sorted := append([]*FundTxOut{}, txouts...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Serial < sorted[j].Serial
+	})
+	return sorted
+}
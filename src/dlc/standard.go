@@ -0,0 +1,137 @@
+// Package dlc project standard.go
+package dlc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Policy of relay
+const (
+	// MaxStandardTxWeight is the maximum weight of standard transaction.
+	MaxStandardTxWeight = 400000
+	// MinRelayFeeRate is the minimum fee rate of relay (satoshi/vbyte).
+	MinRelayFeeRate = 1
+	// DustRelayFeeRate is the fee rate to define dust (satoshi/kvbyte).
+	DustRelayFeeRate = 3000
+)
+
+// DustLimit returns the minimum value of txout to pkScript which is not dust.
+func DustLimit(pkScript []byte) int64 {
+	// size of txout and size of txin to spend it
+	size := int64(8 + wire.VarIntSerializeSize(uint64(len(pkScript))) + len(pkScript))
+	if txscript.IsWitnessProgram(pkScript) {
+		// outpoint, script length, sequence and discounted witness
+		size += 32 + 4 + 1 + 107/WitnessScaleFactor + 4
+	} else {
+		size += 32 + 4 + 1 + 107 + 4
+	}
+	return size * DustRelayFeeRate / 1000
+}
+
+// IsDust returns true if value to pkScript is dust.
+func IsDust(value int64, pkScript []byte) bool {
+	return value < DustLimit(pkScript)
+}
+
+// EstimateWeight returns the weight of tx, where inputs is the weight of all txins with witnesses.
+func EstimateWeight(tx *wire.MsgTx, inputs int64) int64 {
+	weight := TxWeight(len(tx.TxIn), len(tx.TxOut)) + inputs
+	for _, txout := range tx.TxOut {
+		weight += OutputWeight(txout.PkScript)
+	}
+	return weight
+}
+
+// CheckStandard checks tx with the policy of relay.
+// amount is the total value of inputs and weight is the weight of tx with witnesses.
+func CheckStandard(tx *wire.MsgTx, amount, weight int64) error {
+	if tx.Version < 1 || tx.Version > 2 {
+		return fmt.Errorf("non-standard version : %d", tx.Version)
+	}
+	if weight > MaxStandardTxWeight {
+		return fmt.Errorf("transaction is too large : %d", weight)
+	}
+	if len(tx.TxIn) == 0 || len(tx.TxOut) == 0 {
+		return fmt.Errorf("transaction has no txin or txout")
+	}
+	total := int64(0)
+	for i, txout := range tx.TxOut {
+		class := txscript.GetScriptClass(txout.PkScript)
+		if class == txscript.NullDataTy {
+			continue
+		}
+		if class == txscript.NonStandardTy && !txscript.IsWitnessProgram(txout.PkScript) {
+			return fmt.Errorf("non-standard pkScript : txout[%d] %x", i, txout.PkScript)
+		}
+		if IsDust(txout.Value, txout.PkScript) {
+			return fmt.Errorf("dust txout[%d] : %d", i, txout.Value)
+		}
+		total += txout.Value
+	}
+	fee := amount - total
+	min := WeightToFee(weight, MinRelayFeeRate)
+	if fee < min {
+		return fmt.Errorf("fee is less than min relay fee : %d, %d", fee, min)
+	}
+	return nil
+}
+
+// CheckFundTx checks the fund transaction with the policy of relay.
+// amount is the total value of inputs of A and B.
+func (d *Dlc) CheckFundTx(amount int64) error {
+	tx := d.FundTx()
+	inputs := int64(len(tx.TxIn))*P2WPKHInputWeight() + d.rolloverInputWeight()
+	if d.roll != nil {
+		inputs -= P2WPKHInputWeight()
+	}
+	weight := EstimateWeight(tx, inputs)
+	return CheckStandard(tx, amount, weight)
+}
+
+// CheckSettlementTx checks the settlement transaction of rate with the policy of relay.
+func (d *Dlc) CheckSettlementTx(rate *Rate) error {
+	tx := d.SettlementTx(rate)
+	amount := d.FundAmount() + d.SettlementFee()
+	return CheckStandard(tx, amount, EstimateWeight(tx, d.fundInputWeight()))
+}
+
+// CheckRefundTx checks the refund transaction with the policy of relay.
+func (d *Dlc) CheckRefundTx() error {
+	tx := d.RefundTx()
+	amount := d.FundAmount() + d.SettlementFee()
+	return CheckStandard(tx, amount, EstimateWeight(tx, d.fundInputWeight()))
+}
+
+// CheckCloseTx checks the close transaction with the policy of relay.
+func (d *Dlc) CheckCloseTx(amta, amtb int64) error {
+	tx := d.CloseTx(amta, amtb)
+	amount := d.FundAmount() + d.SettlementFee()
+	return CheckStandard(tx, amount, EstimateWeight(tx, d.closeInputWeight()))
+}
+
+// MaxPayoutScriptSize is the maximum size of payout pkScript.
+const MaxPayoutScriptSize = 34
+
+// CheckPayoutScript checks pkScript is a standard type for payout,
+// which is P2PKH, P2SH, P2WPKH, P2WSH or P2TR.
+func CheckPayoutScript(pkScript []byte) error {
+	switch txscript.GetScriptClass(pkScript) {
+	case txscript.PubKeyHashTy, txscript.ScriptHashTy,
+		txscript.WitnessV0PubKeyHashTy, txscript.WitnessV0ScriptHashTy:
+		return nil
+	}
+	if IsP2TRpkScript(pkScript) {
+		return nil
+	}
+	return fmt.Errorf("unsupported payout pkScript : %x", pkScript)
+}
+
+// IsP2TRpkScript returns true if pkScript is P2TR.
+func IsP2TRpkScript(pkScript []byte) bool {
+	// P2TR is OP_1 + <32 bytes x-only public key>
+	return len(pkScript) == 34 && pkScript[0] == txscript.OP_1 &&
+		pkScript[1] == txscript.OP_DATA_32
+}
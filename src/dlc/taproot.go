@@ -0,0 +1,214 @@
+// Package dlc project taproot.go
+package dlc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"musig2"
+	"schnorr"
+)
+
+// TapLeafVersion is the leaf version of tapscript.
+const TapLeafVersion = 0xc0
+
+// controlBlockSize is the size of control block of the fund leaf, which is the only leaf.
+const controlBlockSize = 1 + schnorr.KeySize
+
+// SetTaproot sets the contract mode.
+// In taproot mode, the fund output is P2TR of MuSig2 key of A and B with 2-of-2 tapscript leaf,
+// and the settlement transactions are signed with Schnorr adaptor signatures.
+// The fees are split again by the fee payers.
+func (d *Dlc) SetTaproot(taproot bool) error {
+	d.taproot = taproot
+	if d.payers == nil {
+		return nil
+	}
+	return d.SetFeePayers(d.payers, d.offerA)
+}
+
+// IsTaproot returns true if the contract is in taproot mode.
+func (d *Dlc) IsTaproot() bool {
+	return d.taproot
+}
+
+// FundLeafScript returns the tapscript leaf of fund output.
+func (d *Dlc) FundLeafScript() []byte {
+	if d.puba == nil || d.pubb == nil {
+		return nil
+	}
+	return fundLeafScript(d.puba, d.pubb)
+}
+
+// fundLeafScript returns the tapscript leaf of public keys a and b.
+func fundLeafScript(puba, pubb *btcec.PublicKey) []byte {
+	// fund leaf script:
+	// <x-only public key a>
+	// OP_CHECKSIGVERIFY
+	// <x-only public key b>
+	// OP_CHECKSIG
+	builder := txscript.NewScriptBuilder()
+	builder.AddData(schnorr.XOnly(puba))
+	builder.AddOp(txscript.OP_CHECKSIGVERIFY)
+	builder.AddData(schnorr.XOnly(pubb))
+	builder.AddOp(txscript.OP_CHECKSIG)
+	script, _ := builder.Script()
+	return script
+}
+
+// tapLeafHash returns the hash of tapscript leaf.
+func tapLeafHash(script []byte) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteByte(TapLeafVersion)
+	wire.WriteVarBytes(buf, 0, script)
+	return schnorr.TaggedHash("TapLeaf", buf.Bytes())
+}
+
+// fundKeys returns the internal key of fund output aggregated by MuSig2,
+// and the aggregate tweaked by the fund leaf for the output key.
+func (d *Dlc) fundKeys() (*btcec.PublicKey, *musig2.KeyAggContext, error) {
+	leaf := d.FundLeafScript()
+	if leaf == nil {
+		return nil, nil, fmt.Errorf("not found fund leaf script")
+	}
+	ctx, err := musig2.KeyAgg([]*btcec.PublicKey{d.puba, d.pubb})
+	if err != nil {
+		return nil, nil, err
+	}
+	internal := ctx.PublicKey()
+	tweak := schnorr.TaggedHash("TapTweak", schnorr.XOnly(internal), tapLeafHash(leaf))
+	err = ctx.ApplyTweak(tweak, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return internal, ctx, nil
+}
+
+// fundControlBlock returns the control block to spend fund output by the fund leaf.
+func (d *Dlc) fundControlBlock() ([]byte, error) {
+	internal, ctx, err := d.fundKeys()
+	if err != nil {
+		return nil, err
+	}
+	version := byte(TapLeafVersion)
+	if !schnorr.HasEvenY(ctx.PublicKey()) {
+		version |= 1
+	}
+	return append([]byte{version}, schnorr.XOnly(internal)...), nil
+}
+
+// fundPkScript returns the pkScript of fund output, or nil if the public keys are not set.
+func (d *Dlc) fundPkScript() []byte {
+	if d.puba == nil || d.pubb == nil {
+		return nil
+	}
+	if !d.taproot {
+		return P2WSHpkScript(d.FundScript())
+	}
+	_, ctx, err := d.fundKeys()
+	if err != nil {
+		return nil
+	}
+	return P2TRpkScript(ctx.PublicKey())
+}
+
+// TaprootSigHash returns the BIP341 signature hash with SIGHASH_DEFAULT of txin idx,
+// where prevOuts are the outputs spent by all txins.
+// If leaf is nil, it is for the key path, otherwise for the script path of the leaf.
+func TaprootSigHash(tx *wire.MsgTx, idx int, prevOuts []*wire.TxOut, leaf []byte) ([]byte, error) {
+	if idx < 0 || idx >= len(tx.TxIn) || len(prevOuts) != len(tx.TxIn) {
+		return nil, fmt.Errorf("illegal txin index or prevouts : %d, %d", idx, len(prevOuts))
+	}
+	var prevs, amts, pkScripts, seqs, outs bytes.Buffer
+	for i, txin := range tx.TxIn {
+		op := txin.PreviousOutPoint
+		prevs.Write(op.Hash[:])
+		binary.Write(&prevs, binary.LittleEndian, op.Index)
+		binary.Write(&amts, binary.LittleEndian, prevOuts[i].Value)
+		wire.WriteVarBytes(&pkScripts, 0, prevOuts[i].PkScript)
+		binary.Write(&seqs, binary.LittleEndian, txin.Sequence)
+	}
+	for _, txout := range tx.TxOut {
+		wire.WriteTxOut(&outs, 0, 0, txout)
+	}
+	msg := &bytes.Buffer{}
+	msg.WriteByte(0) // epoch
+	msg.WriteByte(0) // SIGHASH_DEFAULT
+	binary.Write(msg, binary.LittleEndian, tx.Version)
+	binary.Write(msg, binary.LittleEndian, tx.LockTime)
+	for _, buf := range []*bytes.Buffer{&prevs, &amts, &pkScripts, &seqs, &outs} {
+		h := sha256.Sum256(buf.Bytes())
+		msg.Write(h[:])
+	}
+	spendType := byte(0)
+	if leaf != nil {
+		spendType = 2 // ext_flag 1 without annex
+	}
+	msg.WriteByte(spendType)
+	binary.Write(msg, binary.LittleEndian, uint32(idx))
+	if leaf != nil {
+		msg.Write(tapLeafHash(leaf))
+		msg.WriteByte(0) // key version
+		binary.Write(msg, binary.LittleEndian, uint32(0xffffffff))
+	}
+	return schnorr.TaggedHash("TapSighash", msg.Bytes()), nil
+}
+
+// TapscriptWitness returns the witness of tx spending fund output by the fund leaf
+// from the signatures of A and B.
+func (d *Dlc) TapscriptWitness(signa, signb []byte) (wire.TxWitness, error) {
+	control, err := d.fundControlBlock()
+	if err != nil {
+		return nil, err
+	}
+	// The signature of A is on the top of stack.
+	return wire.TxWitness{signb, signa, d.FundLeafScript(), control}, nil
+}
+
+// CloseNonce returns the MuSig2 secret and public nonces of own key
+// for close transaction paying amta to A and amtb to B in taproot mode.
+func (d *Dlc) CloseNonce(amta, amtb int64) (*musig2.SecNonce, []byte, error) {
+	_, ctx, err := d.fundKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+	hash, err := d.FundSigHash(d.CloseTx(amta, amtb), true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return musig2.NonceGen(d.PublicKey(d.isA), schnorr.XOnly(ctx.PublicKey()), hash)
+}
+
+// CloseSession returns the MuSig2 signing session of close transaction
+// paying amta to A and amtb to B with the public nonces of A and B in taproot mode.
+func (d *Dlc) CloseSession(amta, amtb int64, nonces [][]byte) (*musig2.Session, error) {
+	_, ctx, err := d.fundKeys()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := d.FundSigHash(d.CloseTx(amta, amtb), true)
+	if err != nil {
+		return nil, err
+	}
+	aggnonce, err := musig2.NonceAgg(nonces)
+	if err != nil {
+		return nil, err
+	}
+	return musig2.NewSession(ctx, aggnonce, hash)
+}
+
+// P2TRpkScript creates P2TR pkScript
+func P2TRpkScript(pub *btcec.PublicKey) []byte {
+	// P2TR is OP_1 + x-only public key
+	builder := txscript.NewScriptBuilder()
+	builder.AddOp(txscript.OP_1)
+	builder.AddData(schnorr.XOnly(pub))
+	pkScript, _ := builder.Script()
+	return pkScript
+}
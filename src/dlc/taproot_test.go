@@ -0,0 +1,92 @@
+package dlc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+
+	"ec"
+	"schnorr"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestTaprootSigHash tests the key path spending vector of BIP341 with SIGHASH_DEFAULT.
+func TestTaprootSigHash(t *testing.T) {
+	raw := "02000000097de20cbff686da83a54981d2b9bab3586f4ca7e48f57f5b55963115f3b334e9c010000000000000000d7b7cab57b1393ace2d064f4d4a2cb8af6def61273e127517d44759b6dafdd990000000000fffffffff8e1f583384333689228c5d28eac13366be082dc57441760d957275419a418420000000000fffffffff0689180aa63b30cb162a73c6d2a38b7eeda2a83ece74310fda0843ad604853b0100000000feffffffaa5202bdf6d8ccd2ee0f0202afbbb7461d9264a25e5bfd3c5a52ee1239e0ba6c0000000000feffffff956149bdc66faa968eb2be2d2faa29718acbfe3941215893a2a3446d32acd050000000000000000000e664b9773b88c09c32cb70a2a3e4da0ced63b7ba3b22f848531bbb1d5d5f4c94010000000000000000e9aa6b8e6c9de67619e6a3924ae25696bb7b694bb677a632a74ef7eadfd4eabf0000000000ffffffffa778eb6a263dc090464cd125c466b5a99667720b1c110468831d058aa1b82af10100000000ffffffff0200ca9a3b000000001976a91406afd46bcdfd22ef94ac122aa11f241244a37ecc88ac807840cb0000000020ac9a87f5594be208f8532db38cff670c450ed2fea8fcdefcc9a663f78bab962b0065cd1d"
+	utxos := []struct {
+		pkScript string
+		amount   int64
+	}{
+		{"512053a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343", 420000000},
+		{"5120147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3", 462000000},
+		{"76a914751e76e8199196d454941c45d1b3a323f1433bd688ac", 294000000},
+		{"5120e4d810fd50586274face62b8a807eb9719cef49c04177cc6b76a9a4251d5450e", 504000000},
+		{"512091b64d5324723a985170e4dc5a0f84c041804f2cd12660fa5dec09fc21783605", 630000000},
+		{"00147dd65592d0ab2fe0d0257d571abf032cd9db93dc", 378000000},
+		{"512075169f4001aa68f15bbed28b218df1d0a62cbbcf1188c6665110c293c907b831", 672000000},
+		{"5120712447206d7a5238acc7ff53fbe94a3b64539ad291c7cdbc490b7577e4b17df5", 546000000},
+		{"512077e30a5522dd9f894c3f8b8bd4c4b2cf82ca7da8a3ea6a239655c39c050ab220", 588000000},
+	}
+	tx := wire.NewMsgTx(0)
+	err := tx.Deserialize(bytes.NewReader(mustHex(t, raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevOuts := []*wire.TxOut{}
+	for _, u := range utxos {
+		prevOuts = append(prevOuts, wire.NewTxOut(u.amount, mustHex(t, u.pkScript)))
+	}
+	// input 4 is signed with SIGHASH_DEFAULT
+	hash, err := TaprootSigHash(tx, 4, prevOuts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := mustHex(t, "4f900a0bae3f1446fd48490c2958b5a023228f01661cda3496a11da502a7f7ef")
+	if !bytes.Equal(hash, expected) {
+		t.Fatalf("sighash : %x", hash)
+	}
+	_, err = TaprootSigHash(tx, 9, prevOuts, nil)
+	if err == nil {
+		t.Fatalf("sighash of txin out of range")
+	}
+	_, err = TaprootSigHash(tx, 0, prevOuts[1:], nil)
+	if err == nil {
+		t.Fatalf("sighash without all prevouts")
+	}
+}
+
+// TestTapLeaf tests the script tree vector of BIP341 with a single leaf.
+func TestTapLeaf(t *testing.T) {
+	internal, err := schnorr.ParseXOnly(mustHex(t, "187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := mustHex(t, "20d85a959b0290bf19bb89ed43c916be835475d013da4b362117393e25a48229b8ac")
+	leaf := tapLeafHash(script)
+	if !bytes.Equal(leaf, mustHex(t, "5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21")) {
+		t.Fatalf("leaf hash : %x", leaf)
+	}
+	tweak := schnorr.TaggedHash("TapTweak", schnorr.XOnly(internal), leaf)
+	if !bytes.Equal(tweak, mustHex(t, "cbd8679ba636c1110ea247542cfbd964131a6be84f873f7f3b62a777528ed001")) {
+		t.Fatalf("tweak : %x", tweak)
+	}
+	output := ec.Add(internal, ec.BaseMul(tweak))
+	pkScript := P2TRpkScript(output)
+	if !bytes.Equal(pkScript, mustHex(t, "5120147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3")) {
+		t.Fatalf("pkScript : %x", pkScript)
+	}
+	// The control block c1 has the odd parity of the output key.
+	if schnorr.HasEvenY(output) {
+		t.Fatalf("output key has even y")
+	}
+}
@@ -0,0 +1,42 @@
+// Package dlc project timelock.go
+package dlc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// MinRefundGap is the minimum blocks from the target block to the refund locktime.
+const MinRefundGap = 144
+
+// BlockInterval is the expected seconds per block to compare time with height.
+const BlockInterval = 600
+
+// SetTimelocks sets the refund locktime.
+// The locktime is a block height if less than txscript.LockTimeThreshold, otherwise a unix time.
+func (d *Dlc) SetTimelocks(locktime uint32) error {
+	if locktime == 0 {
+		return fmt.Errorf("refund locktime is zero")
+	}
+	d.locktime = locktime
+	return nil
+}
+
+// CheckTimelocks checks the refund locktime against the maturity of contract.
+// count and mediantime are the block count and the median time of own node,
+// which are used to estimate the time of the target block.
+func (d *Dlc) CheckTimelocks(count int, mediantime int64) error {
+	if d.locktime < txscript.LockTimeThreshold {
+		min := d.height + MinRefundGap
+		if int64(d.locktime) < int64(min) {
+			return fmt.Errorf("refund locktime is too early : %d, %d", d.locktime, min)
+		}
+		return nil
+	}
+	min := mediantime + int64(d.height-count+MinRefundGap)*BlockInterval
+	if int64(d.locktime) < min {
+		return fmt.Errorf("refund locktime is too early : %d, %d", d.locktime, min)
+	}
+	return nil
+}
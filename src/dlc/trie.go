@@ -0,0 +1,111 @@
+// Package dlc project trie.go
+package dlc
+
+import (
+	"bytes"
+	"fmt"
+
+	"oracle"
+)
+
+// rateTrie is the trie of rate prefixes.
+// The depth is the digit from the most significant,
+// and the rate is at the node of its last message.
+// The cancel rate is kept at the root.
+type rateTrie struct {
+	rate     *Rate                // rate of the prefix ending here
+	children map[string]*rateTrie // children by message
+	cancel   *Rate                // rate of the cancelled event
+}
+
+// newRateTrie returns the trie of rates with length messages.
+// It returns error if the prefixes of rates overlap.
+func newRateTrie(rates []*Rate, length int) (*rateTrie, error) {
+	root := &rateTrie{}
+	for _, r := range rates {
+		err := root.insert(r, length)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// insert adds the rate at the node of its prefix.
+func (t *rateTrie) insert(rate *Rate, length int) error {
+	if len(rate.msgs) != length {
+		return fmt.Errorf("illegal rate messages : %x", rate.msgs)
+	}
+	if isCancel(rate.msgs, length) {
+		if t.cancel != nil {
+			return fmt.Errorf("cancel rates overlap : %x", rate.msgs)
+		}
+		t.cancel = rate
+		return nil
+	}
+	node := t
+	i := length - 1
+	for ; i >= 0 && rate.msgs[i] != nil; i-- {
+		if node.rate != nil {
+			return fmt.Errorf("rate prefixes overlap : %x, %x", node.rate.msgs, rate.msgs)
+		}
+		if node.children == nil {
+			node.children = map[string]*rateTrie{}
+		}
+		key := string(rate.msgs[i])
+		child := node.children[key]
+		if child == nil {
+			child = &rateTrie{}
+			node.children[key] = child
+		}
+		node = child
+	}
+	// the rest digits must be any
+	for j := i; j >= 0; j-- {
+		if rate.msgs[j] != nil {
+			return fmt.Errorf("rate messages are not a prefix : %x", rate.msgs)
+		}
+	}
+	if node.rate != nil || len(node.children) > 0 {
+		return fmt.Errorf("rate prefixes overlap : %x", rate.msgs)
+	}
+	node.rate = rate
+	return nil
+}
+
+// search returns the rate whose prefix matches the messages, or nil.
+func (t *rateTrie) search(msgs [][]byte, length int) *Rate {
+	if isCancel(msgs, length) {
+		return t.cancel
+	}
+	node := t
+	for i := length - 1; i >= 0; i-- {
+		if node.rate != nil {
+			return node.rate
+		}
+		node = node.children[string(msgs[i])]
+		if node == nil {
+			return nil
+		}
+	}
+	return node.rate
+}
+
+// isCancel returns true if the most significant message is the cancel message.
+func isCancel(msgs [][]byte, length int) bool {
+	return bytes.Equal(msgs[length-1], oracle.CancelMessage)
+}
+
+// rateIndex returns the trie of rates, which is built with the rates by Rates, Validate or decoding.
+func (d *Dlc) rateIndex() (*rateTrie, error) {
+	if d.trie == nil {
+		return nil, fmt.Errorf("rates are not indexed")
+	}
+	return d.trie, nil
+}
+
+// resetRates clears the cache of rates and the trie.
+func (d *Dlc) resetRates() {
+	d.rates = nil
+	d.trie = nil
+}
@@ -0,0 +1,66 @@
+package dlc
+
+import (
+	"testing"
+
+	"oracle"
+)
+
+func TestRateTrie(t *testing.T) {
+	d, err := NewDlc(60000, 40000, 2, 3, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetGameConditions(1000, 2)
+	rates := d.Rates()
+	if d.trie == nil {
+		t.Fatalf("trie is not built with rates")
+	}
+	for _, msgs := range [][][]byte{{{0x00}, {0x00}}, {{0x05}, {0x03}}, {nil, oracle.CancelMessage}} {
+		rate, err := d.searchRate(msgs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rate.msgs) != 2 {
+			t.Fatalf("rate of %x : %v", msgs, rate)
+		}
+	}
+	es := ValidationErrors{}
+	d.validateRates(&es)
+	if len(es) != 0 {
+		t.Fatal(es)
+	}
+	// The overlap of the cancel rates is found by the trie.
+	d.rates = append(rates, NewRate(rates[len(rates)-1].msgs, d.famta, d.famtb))
+	d.trie = nil
+	es = ValidationErrors{}
+	d.validateRates(&es)
+	if !es.Has(InvalidOverlap) {
+		t.Fatalf("overlapped rates are accepted : %v", es)
+	}
+	if _, err = d.searchRate([][]byte{{0x00}, {0x00}}); err == nil {
+		t.Fatalf("rate is found without trie")
+	}
+}
+
+func TestRateTrieDecoded(t *testing.T) {
+	bs, err := testDlc(t).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Dlc{}
+	err = d.UnmarshalBinary(bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rate, err := d.searchRate([][]byte{{0x01}, {0x02}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate != d.rates[1] {
+		t.Fatalf("rate : %v", rate)
+	}
+	if _, err = d.searchRate([][]byte{{0x01}, {0x03}}); err == nil {
+		t.Fatalf("rate of uncovered outcome is found")
+	}
+}
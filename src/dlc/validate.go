@@ -0,0 +1,255 @@
+// Package dlc project validate.go
+package dlc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Kinds of validation error
+const (
+	InvalidKeys      = "keys"      // public keys are missing or identical
+	InvalidInputs    = "inputs"    // fund txins are missing or duplicate
+	InvalidTimelocks = "timelocks" // refund locktime is illegal
+	InvalidCoverage  = "coverage"  // outcomes are not covered by rates
+	InvalidOverlap   = "overlap"   // outcomes are covered by multiple rates
+	InvalidAmount    = "amount"    // amounts of rate are not conserved
+	InvalidFee       = "fee"       // fees are not sufficient
+)
+
+// ValidationError is a problem of contract.
+type ValidationError struct {
+	Kind string // kind of problem
+	Msg  string // description
+}
+
+// Error returns the kind and description.
+func (e *ValidationError) Error() string {
+	return e.Kind + " : " + e.Msg
+}
+
+// ValidationErrors is all problems of contract.
+type ValidationErrors []*ValidationError
+
+// Error returns the problems joined.
+func (es ValidationErrors) Error() string {
+	strs := []string{}
+	for _, e := range es {
+		strs = append(strs, e.Error())
+	}
+	return "invalid contract : " + strings.Join(strs, ", ")
+}
+
+// Has returns true if the problems have the kind.
+func (es ValidationErrors) Has(kind string) bool {
+	for _, e := range es {
+		if e.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (es *ValidationErrors) add(kind, format string, a ...interface{}) {
+	*es = append(*es, &ValidationError{kind, fmt.Sprintf(format, a...)})
+}
+
+// Validate checks the contract before signing.
+// It returns ValidationErrors of all problems, or nil.
+func (d *Dlc) Validate() error {
+	return d.validate(true)
+}
+
+// ValidateOffer checks the contract offered,
+// where the public key and txins of the acceptor are not set yet.
+// It returns ValidationErrors of all problems, or nil.
+func (d *Dlc) ValidateOffer() error {
+	return d.validate(false)
+}
+
+func (d *Dlc) validate(accepted bool) error {
+	es := ValidationErrors{}
+	d.validateKeys(&es, accepted)
+	d.validateInputs(&es, accepted)
+	d.validateTimelocks(&es)
+	d.validateRates(&es)
+	d.validateFees(&es)
+	if len(es) > 0 {
+		return es
+	}
+	return nil
+}
+
+func (d *Dlc) validateKeys(es *ValidationErrors, accepted bool) {
+	for _, isA := range []bool{true, false} {
+		if d.PublicKey(isA) == nil && (accepted || isA == d.offerA) {
+			es.add(InvalidKeys, "public key of %s is missing", partyName(isA))
+		}
+	}
+	if d.puba != nil && d.pubb != nil && d.puba.IsEqual(d.pubb) {
+		es.add(InvalidKeys, "public keys of A and B are identical")
+	}
+	if d.pubo != nil {
+		for _, isA := range []bool{true, false} {
+			if pub := d.PublicKey(isA); pub != nil && pub.IsEqual(d.pubo) {
+				es.add(InvalidKeys, "public key of %s is oracle's", partyName(isA))
+			}
+		}
+	}
+}
+
+func (d *Dlc) validateInputs(es *ValidationErrors, accepted bool) {
+	for _, isA := range []bool{true, false} {
+		txins := d.btxins
+		if isA {
+			txins = d.atxins
+		}
+		if len(txins) == 0 && d.FundTxAmount(isA) > 0 && (accepted || isA == d.offerA) {
+			es.add(InvalidInputs, "txins of %s are missing", partyName(isA))
+		}
+	}
+	if accepted && len(d.ownTxIns()) == 0 {
+		es.add(InvalidInputs, "txins are missing")
+	}
+	ops := map[wire.OutPoint]bool{}
+	for _, txin := range append(d.ownTxIns(), d.otxins...) {
+		op := txin.TxIn.PreviousOutPoint
+		if ops[op] {
+			es.add(InvalidInputs, "duplicate txin : %v", op)
+		}
+		ops[op] = true
+	}
+	err := d.checkSerials()
+	if err != nil {
+		es.add(InvalidInputs, "%v", err)
+	}
+}
+
+func (d *Dlc) validateTimelocks(es *ValidationErrors) {
+	if d.locktime == 0 {
+		es.add(InvalidTimelocks, "refund locktime is not set")
+	} else if d.locktime < txscript.LockTimeThreshold &&
+		int64(d.locktime) < int64(d.height+MinRefundGap) {
+		es.add(InvalidTimelocks, "refund locktime is earlier than maturity : %d, %d",
+			d.locktime, d.height+MinRefundGap)
+	}
+}
+
+// validateRates checks the prefixes of rates cover all outcomes just once,
+// and the amounts of each rate are conserved.
+// The trie of rates to search is built here.
+func (d *Dlc) validateRates(es *ValidationErrors) {
+	if d.length < 1 || d.length > MaxGameLength {
+		es.add(InvalidCoverage, "illegal length : %d", d.length)
+		return
+	}
+	amount := d.FundAmount()
+	ivs := []*interval{}
+	cancels := 0
+	for _, r := range d.Rates() {
+		if r.amta < 0 || r.amtb < 0 || r.amta+r.amtb != amount {
+			es.add(InvalidAmount, "rate amounts are not the fund amount : %d, %d, %d",
+				r.amta, r.amtb, amount)
+		}
+		if len(r.msgs) != d.length {
+			es.add(InvalidCoverage, "illegal rate messages : %x", r.msgs)
+			continue
+		}
+		if isCancel(r.msgs, d.length) {
+			cancels++
+			if r.amta != d.famta || r.amtb != d.famtb {
+				es.add(InvalidAmount, "cancel amounts are not the collaterals : %d, %d",
+					r.amta, r.amtb)
+			}
+			continue
+		}
+		iv, err := prefixInterval(r.msgs)
+		if err != nil {
+			es.add(InvalidCoverage, "%v", err)
+			continue
+		}
+		ivs = append(ivs, iv)
+	}
+	if cancels != 1 {
+		es.add(InvalidCoverage, "cancel rates are not one : %d", cancels)
+	}
+	sort.Slice(ivs, func(i, j int) bool {
+		return ivs[i].begin < ivs[j].begin
+	})
+	next := int64(0)
+	for _, iv := range ivs {
+		if iv.begin > next {
+			es.add(InvalidCoverage, "outcomes are not covered : %d-%d", next, iv.begin-1)
+		} else if iv.begin < next {
+			es.add(InvalidOverlap, "outcomes are covered twice : %d-%d", iv.begin, next-1)
+		}
+		if iv.end+1 > next {
+			next = iv.end + 1
+		}
+	}
+	if n := outcomes(d.length); next < n {
+		es.add(InvalidCoverage, "outcomes are not covered : %d-%d", next, n-1)
+	}
+	trie, err := newRateTrie(d.Rates(), d.length)
+	if err != nil {
+		es.add(InvalidOverlap, "%v", err)
+		return
+	}
+	d.trie = trie
+}
+
+// prefixInterval returns the outcomes covered by the prefix messages.
+func prefixInterval(msgs [][]byte) (*interval, error) {
+	begin, size := int64(0), int64(1)
+	prefix := true
+	for i := len(msgs) - 1; i >= 0; i-- {
+		m := msgs[i]
+		if m == nil {
+			prefix = false
+			begin *= DigitBase
+			size *= DigitBase
+			continue
+		}
+		if !prefix || len(m) != 1 {
+			return nil, fmt.Errorf("messages are not a prefix : %x", msgs)
+		}
+		begin = begin*DigitBase + int64(m[0])
+	}
+	return &interval{begin, begin + size - 1, 0}, nil
+}
+
+func (d *Dlc) validateFees(es *ValidationErrors) {
+	if d.famta < 0 || d.famtb < 0 || d.FundAmount() <= 0 {
+		es.add(InvalidAmount, "illegal collaterals : %d, %d", d.famta, d.famtb)
+	}
+	if d.fefee < MinRelayFeeRate || d.sefee < MinRelayFeeRate {
+		es.add(InvalidFee, "fee rates are less than relay fee : %d, %d", d.fefee, d.sefee)
+	}
+	if d.payers == nil {
+		es.add(InvalidFee, "fee payers are not set")
+		return
+	}
+	if ffee := WeightToFee(d.FundBaseWeight(), d.fefee); d.ffeea+d.ffeeb < ffee {
+		es.add(InvalidFee, "fund base fee is short : %d, %d", d.ffeea+d.ffeeb, ffee)
+	}
+	if sfee := WeightToFee(d.SettlementTxWeight(maxPayoutScript), d.sefee); d.SettlementFee() < sfee {
+		es.add(InvalidFee, "settlement fee is short : %d, %d", d.SettlementFee(), sfee)
+	}
+	for _, isA := range []bool{true, false} {
+		if d.RefundAmount(isA) < 0 {
+			es.add(InvalidFee, "refund fee is over the collateral of %s : %d",
+				partyName(isA), d.RefundAmount(isA))
+		}
+	}
+}
+
+func partyName(isA bool) string {
+	if isA {
+		return "A"
+	}
+	return "B"
+}
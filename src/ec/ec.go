@@ -0,0 +1,96 @@
+// Package ec project ec.go
+package ec
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// curve is secp256k1.
+var curve = btcec.S256()
+
+// New returns the public key of the coordinates.
+func New(x, y *big.Int) *btcec.PublicKey {
+	return &btcec.PublicKey{Curve: curve, X: x, Y: y}
+}
+
+// Add returns p + q, or nil if it is the point at infinity.
+// nil is the point at infinity.
+func Add(p, q *btcec.PublicKey) *btcec.PublicKey {
+	if p == nil {
+		return q
+	}
+	if q == nil {
+		return p
+	}
+	x, y := curve.Add(p.X, p.Y, q.X, q.Y)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil
+	}
+	return New(x, y)
+}
+
+// Neg returns -p.
+func Neg(p *btcec.PublicKey) *btcec.PublicKey {
+	if p == nil {
+		return nil
+	}
+	return New(p.X, new(big.Int).Sub(curve.P, p.Y))
+}
+
+// Mul returns k*p, or nil if it is the point at infinity.
+func Mul(p *btcec.PublicKey, k []byte) *btcec.PublicKey {
+	if p == nil {
+		return nil
+	}
+	x, y := curve.ScalarMult(p.X, p.Y, k)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil
+	}
+	return New(x, y)
+}
+
+// BaseMul returns k*G, or nil if it is the point at infinity.
+func BaseMul(k []byte) *btcec.PublicKey {
+	x, y := curve.ScalarBaseMult(k)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil
+	}
+	return New(x, y)
+}
+
+// IsEqual returns true if p and q are the same point.
+func IsEqual(p, q *btcec.PublicKey) bool {
+	if p == nil || q == nil {
+		return p == q
+	}
+	return p.X.Cmp(q.X) == 0 && p.Y.Cmp(q.Y) == 0
+}
+
+// Pad32 returns b padded to 32 bytes big endian.
+func Pad32(b []byte) []byte {
+	return append(bytes.Repeat([]byte{0}, 32-len(b)), b...)
+}
+
+// XorBytes sets a to a xor b.
+func XorBytes(a, b []byte) {
+	for i := range a {
+		a[i] ^= b[i]
+	}
+}
+
+// Parse returns the public key of the bytes.
+// Unlike btcec.ParsePubKey, the x coordinate over the field is not accepted.
+func Parse(b []byte) (*btcec.PublicKey, error) {
+	p, err := btcec.ParsePubKey(b, curve)
+	if err != nil {
+		return nil, err
+	}
+	if p.X.Cmp(curve.P) >= 0 {
+		return nil, fmt.Errorf("public key is over the field : %x", b)
+	}
+	return p, nil
+}
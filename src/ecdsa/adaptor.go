@@ -0,0 +1,243 @@
+// Package ecdsa project adaptor.go
+package ecdsa
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+
+	"ec"
+	"scalar"
+	"schnorr"
+)
+
+// Sizes of ECDSA adaptor signature (byte)
+const (
+	// pointSize is the size of compressed point.
+	pointSize = 33
+	// proofSize is the size of DLEQ proof, which is the challenge and the response.
+	proofSize = 32 + 32
+	// AdaptorSize is the size of adaptor signature,
+	// which is the encrypted nonce point, the nonce point, the pre-signature and the DLEQ proof.
+	AdaptorSize = pointSize + pointSize + 32 + proofSize
+)
+
+// curve is secp256k1.
+var curve = btcec.S256()
+
+// EncSign returns the ECDSA adaptor signature of the hash by the private key encrypted to the point.
+// The signature is completed with the discrete log of the point by Adapt.
+// aux is the 32 bytes auxiliary random data.
+func EncSign(pri *btcec.PrivateKey, hash []byte, point *btcec.PublicKey, aux []byte) ([]byte, error) {
+	if len(aux) != 32 {
+		return nil, fmt.Errorf("illegal aux size : %d", len(aux))
+	}
+	var d scalar.Scalar
+	db := pri.Serialize()
+	d.SetBytes(db)
+	scalar.Zero(db)
+	defer d.Zero()
+	if d.IsZero() {
+		return nil, fmt.Errorf("private key is zero")
+	}
+	pub := (*btcec.PublicKey)(&pri.PublicKey)
+	// nonce, which depends on the point not to be reused for another point
+	t := d.Bytes()
+	defer scalar.Zero(t[:])
+	ec.XorBytes(t[:], schnorr.TaggedHash("DLC/adaptor/ecdsa/aux", aux))
+	var k scalar.Scalar
+	k.SetBytes(schnorr.TaggedHash("DLC/adaptor/ecdsa/nonce",
+		t[:], point.SerializeCompressed(), pub.SerializeCompressed(), hash))
+	defer k.Zero()
+	if k.IsZero() {
+		return nil, fmt.Errorf("nonce is zero")
+	}
+	// R = k*Y, Ra = k*G
+	kb := k.Bytes()
+	defer scalar.Zero(kb[:])
+	r := ec.Mul(point, kb[:])
+	ra := ec.BaseMul(kb[:])
+	if r == nil || ra == nil {
+		return nil, fmt.Errorf("nonce point is infinity")
+	}
+	// s' = k^-1 * (m + r*d)
+	var m, rx, s scalar.Scalar
+	m.SetBytes(hash)
+	rx.SetBig(r.X)
+	if rx.IsZero() {
+		return nil, fmt.Errorf("nonce x is zero")
+	}
+	s.Mul(&rx, &d)
+	s.Add(&s, &m)
+	var ki scalar.Scalar
+	ki.Inverse(&k)
+	defer ki.Zero()
+	s.Mul(&s, &ki)
+	if s.IsZero() {
+		return nil, fmt.Errorf("pre-signature is zero")
+	}
+	proof, err := proveDLEQ(&k, point, ra, r, aux)
+	if err != nil {
+		return nil, err
+	}
+	sb := s.Bytes()
+	asig := append(r.SerializeCompressed(), ra.SerializeCompressed()...)
+	asig = append(asig, sb[:]...)
+	asig = append(asig, proof...)
+	err = EncVerify(pub, hash, point, asig)
+	if err != nil {
+		return nil, err
+	}
+	return asig, nil
+}
+
+// EncVerify verifies the ECDSA adaptor signature of the hash by the public key encrypted to the point.
+func EncVerify(pub *btcec.PublicKey, hash []byte, point *btcec.PublicKey, asig []byte) error {
+	r, ra, s, err := parseAdaptor(asig)
+	if err != nil {
+		return err
+	}
+	// R and Ra have the same discrete log for Y and G
+	err = verifyDLEQ(asig[pointSize*2+32:], point, ra, r)
+	if err != nil {
+		return err
+	}
+	// s'*Ra = m*G + r*P
+	rx := new(big.Int).Mod(r.X, curve.N)
+	if rx.Sign() == 0 {
+		return fmt.Errorf("nonce x is zero : %x", asig)
+	}
+	m := new(big.Int).SetBytes(hash)
+	m.Mod(m, curve.N)
+	lhs := ec.Mul(ra, s.Bytes())
+	rhs := ec.Add(ec.BaseMul(m.Bytes()), ec.Mul(pub, rx.Bytes()))
+	if !ec.IsEqual(lhs, rhs) {
+		return fmt.Errorf("verify fail : %x", asig)
+	}
+	return nil
+}
+
+// Adapt returns the DER signature from the ECDSA adaptor signature
+// and the discrete log of the point encrypted to in 32 bytes big endian.
+func Adapt(asig []byte, secret []byte) ([]byte, error) {
+	r, _, s, err := parseAdaptor(asig)
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) != 32 {
+		return nil, fmt.Errorf("illegal secret size : %d", len(secret))
+	}
+	// s = s' * y^-1
+	var st, y scalar.Scalar
+	st.SetBig(s)
+	y.SetBytes(secret)
+	defer y.Zero()
+	if y.IsZero() {
+		return nil, fmt.Errorf("secret is zero")
+	}
+	y.Inverse(&y)
+	st.Mul(&st, &y)
+	sig := &btcec.Signature{R: new(big.Int).Mod(r.X, curve.N), S: st.Big()}
+	// Serialize returns the signature of low s.
+	return sig.Serialize(), nil
+}
+
+// Extract returns the discrete log of the point encrypted to
+// from the ECDSA adaptor signature and the DER signature adapted.
+func Extract(asig, sig []byte, point *btcec.PublicKey) (*big.Int, error) {
+	r, _, s, err := parseAdaptor(asig)
+	if err != nil {
+		return nil, err
+	}
+	es, err := btcec.ParseDERSignature(sig, curve)
+	if err != nil {
+		return nil, err
+	}
+	if es.R.Cmp(new(big.Int).Mod(r.X, curve.N)) != 0 {
+		return nil, fmt.Errorf("signature does not match the adaptor : %x", sig)
+	}
+	// y = s' * s^-1, or its negation for low s
+	var y, si scalar.Scalar
+	y.SetBig(s)
+	si.SetBig(es.S)
+	si.Inverse(&si)
+	y.Mul(&y, &si)
+	for i := 0; i < 2; i++ {
+		yb := y.Bytes()
+		if ec.IsEqual(ec.BaseMul(yb[:]), point) {
+			return y.Big(), nil
+		}
+		y.Neg(&y)
+	}
+	return nil, fmt.Errorf("signature does not match the point : %x", sig)
+}
+
+// proveDLEQ returns the proof that Ra = k*G and R = k*Y have the same discrete log k.
+func proveDLEQ(k *scalar.Scalar, point, ra, r *btcec.PublicKey, aux []byte) ([]byte, error) {
+	kb := k.Bytes()
+	defer scalar.Zero(kb[:])
+	var a scalar.Scalar
+	a.SetBytes(schnorr.TaggedHash("DLC/adaptor/ecdsa/dleq/nonce",
+		kb[:], aux, point.SerializeCompressed(), ra.SerializeCompressed(), r.SerializeCompressed()))
+	defer a.Zero()
+	if a.IsZero() {
+		return nil, fmt.Errorf("proof nonce is zero")
+	}
+	ab := a.Bytes()
+	defer scalar.Zero(ab[:])
+	var e, z scalar.Scalar
+	e.SetBytes(dleqChallenge(point, ra, r, ec.BaseMul(ab[:]), ec.Mul(point, ab[:])))
+	// z = a + e*k
+	z.Mul(&e, k)
+	z.Add(&z, &a)
+	eb, zb := e.Bytes(), z.Bytes()
+	return append(eb[:], zb[:]...), nil
+}
+
+// verifyDLEQ verifies the proof that Ra = k*G and R = k*Y have the same discrete log.
+func verifyDLEQ(proof []byte, point, ra, r *btcec.PublicKey) error {
+	e := new(big.Int).SetBytes(proof[:32])
+	z := new(big.Int).SetBytes(proof[32:])
+	if e.Cmp(curve.N) >= 0 || z.Cmp(curve.N) >= 0 {
+		return fmt.Errorf("illegal DLEQ proof : %x", proof)
+	}
+	// A1 = z*G - e*Ra, A2 = z*Y - e*R
+	a1 := ec.Add(ec.BaseMul(z.Bytes()), ec.Neg(ec.Mul(ra, e.Bytes())))
+	a2 := ec.Add(ec.Mul(point, z.Bytes()), ec.Neg(ec.Mul(r, e.Bytes())))
+	if a1 == nil || a2 == nil {
+		return fmt.Errorf("verify DLEQ proof fail : %x", proof)
+	}
+	c := new(big.Int).SetBytes(dleqChallenge(point, ra, r, a1, a2))
+	if c.Mod(c, curve.N).Cmp(e) != 0 {
+		return fmt.Errorf("verify DLEQ proof fail : %x", proof)
+	}
+	return nil
+}
+
+// dleqChallenge returns the challenge of DLEQ proof.
+func dleqChallenge(point, ra, r, a1, a2 *btcec.PublicKey) []byte {
+	return schnorr.TaggedHash("DLC/adaptor/ecdsa/dleq", point.SerializeCompressed(),
+		ra.SerializeCompressed(), r.SerializeCompressed(),
+		a1.SerializeCompressed(), a2.SerializeCompressed())
+}
+
+// parseAdaptor returns the encrypted nonce point, the nonce point and the pre-signature.
+func parseAdaptor(asig []byte) (*btcec.PublicKey, *btcec.PublicKey, *big.Int, error) {
+	if len(asig) != AdaptorSize {
+		return nil, nil, nil, fmt.Errorf("illegal adaptor signature size : %d", len(asig))
+	}
+	r, err := ec.Parse(asig[:pointSize])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ra, err := ec.Parse(asig[pointSize : pointSize*2])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	s := new(big.Int).SetBytes(asig[pointSize*2 : pointSize*2+32])
+	if s.Sign() == 0 || s.Cmp(curve.N) >= 0 {
+		return nil, nil, nil, fmt.Errorf("illegal adaptor signature : %x", asig)
+	}
+	return r, ra, s, nil
+}
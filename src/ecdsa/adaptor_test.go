@@ -0,0 +1,135 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+func testKey(i byte) (*btcec.PrivateKey, *btcec.PublicKey) {
+	k := sha256.Sum256([]byte{'k', i})
+	return btcec.PrivKeyFromBytes(curve, k[:])
+}
+
+func testSecret(i byte) ([]byte, *btcec.PublicKey) {
+	k := sha256.Sum256([]byte{'s', i})
+	_, pub := btcec.PrivKeyFromBytes(curve, k[:])
+	return k[:], pub
+}
+
+func TestAdaptor(t *testing.T) {
+	halfN := new(big.Int).Rsh(curve.N, 1)
+	negated := map[bool]int{}
+	for i := byte(0); i < 16; i++ {
+		pri, pub := testKey(i)
+		secret, point := testSecret(i)
+		hash := sha256.Sum256([]byte{i})
+		aux := bytes.Repeat([]byte{i}, 32)
+		asig, err := EncSign(pri, hash[:], point, aux)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(asig) != AdaptorSize {
+			t.Fatalf("adaptor size : %d", len(asig))
+		}
+		err = EncVerify(pub, hash[:], point, asig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := Adapt(asig, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		es, err := btcec.ParseDERSignature(sig, curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !es.Verify(hash[:], pub) {
+			t.Fatalf("adapted signature is not valid : %x", sig)
+		}
+		if es.S.Cmp(halfN) > 0 {
+			t.Fatalf("adapted signature is not low s : %x", sig)
+		}
+		// s = s' * y^-1 is negated by Serialize when it is high.
+		_, _, s, _ := parseAdaptor(asig)
+		raw := new(big.Int).ModInverse(new(big.Int).SetBytes(secret), curve.N)
+		raw.Mul(raw, s).Mod(raw, curve.N)
+		negated[raw.Cmp(halfN) > 0]++
+		y, err := Extract(asig, sig, point)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if y.Cmp(new(big.Int).SetBytes(secret)) != 0 {
+			t.Fatalf("extracted secret mismatch : %x, %x", y, secret)
+		}
+	}
+	if negated[true] == 0 || negated[false] == 0 {
+		t.Fatalf("low s branches are not covered : %v", negated)
+	}
+}
+
+func TestAdaptorErrors(t *testing.T) {
+	pri, pub := testKey(0)
+	secret, point := testSecret(0)
+	_, other := testSecret(1)
+	hash := sha256.Sum256([]byte("hash"))
+	aux := make([]byte, 32)
+	asig, err := EncSign(pri, hash[:], point, aux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every byte of the DLEQ proof is checked
+	for i := pointSize*2 + 32; i < AdaptorSize; i++ {
+		tampered := append([]byte{}, asig...)
+		tampered[i] ^= 0x01
+		if EncVerify(pub, hash[:], point, tampered) == nil {
+			t.Fatalf("tampered proof at %d is verified", i)
+		}
+	}
+	tampered := append([]byte{}, asig...)
+	tampered[pointSize*2] ^= 0x01
+	if EncVerify(pub, hash[:], point, tampered) == nil {
+		t.Fatalf("tampered pre-signature is verified")
+	}
+	if EncVerify(pub, hash[:], other, asig) == nil {
+		t.Fatalf("adaptor is verified by another point")
+	}
+	_, opub := testKey(1)
+	if EncVerify(opub, hash[:], point, asig) == nil {
+		t.Fatalf("adaptor is verified by another key")
+	}
+	if EncVerify(pub, hash[:], point, asig[:AdaptorSize-1]) == nil {
+		t.Fatalf("short adaptor is verified")
+	}
+	_, err = EncSign(pri, hash[:], point, aux[:31])
+	if err == nil {
+		t.Fatalf("short aux is accepted")
+	}
+	sig, err := Adapt(asig, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Extract(asig, sig, other); err == nil {
+		t.Fatalf("secret is extracted for another point")
+	}
+	osig, err := pri.Sign(hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Extract(asig, osig.Serialize(), point); err == nil {
+		t.Fatalf("secret is extracted from another signature")
+	}
+	if _, err = Adapt(asig, make([]byte, 32)); err == nil {
+		t.Fatalf("zero secret is adapted")
+	}
+	// N is reduced to zero.
+	if _, err = Adapt(asig, curve.N.Bytes()); err == nil {
+		t.Fatalf("secret of N is adapted")
+	}
+	if _, err = Adapt(asig, secret[1:]); err == nil {
+		t.Fatalf("short secret is adapted")
+	}
+}
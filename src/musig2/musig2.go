@@ -0,0 +1,360 @@
+// Package musig2 project musig2.go
+package musig2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+
+	"ec"
+	"scalar"
+	"schnorr"
+)
+
+// Sizes of BIP327 data (byte)
+const (
+	// PubNonceSize is the size of public nonce and aggregate nonce.
+	PubNonceSize = 33 * 2
+	// PartialSigSize is the size of partial signature.
+	PartialSigSize = 32
+)
+
+// curve is secp256k1.
+var curve = btcec.S256()
+
+// KeyAggContext is the aggregate public key with the tweaks.
+type KeyAggContext struct {
+	pks  [][]byte         // compressed public keys in order
+	pk2  []byte           // second distinct public key
+	list []byte           // hash of public keys
+	q    *btcec.PublicKey // aggregate public key
+	gacc *big.Int         // accumulated sign
+	tacc *big.Int         // accumulated tweak
+}
+
+// KeyAgg returns the aggregate of the public keys.
+// The order of the keys is a part of the aggregation.
+func KeyAgg(pubs []*btcec.PublicKey) (*KeyAggContext, error) {
+	if len(pubs) == 0 {
+		return nil, fmt.Errorf("public keys are empty")
+	}
+	c := &KeyAggContext{}
+	for _, pub := range pubs {
+		c.pks = append(c.pks, pub.SerializeCompressed())
+	}
+	c.pk2 = make([]byte, 33)
+	for _, pk := range c.pks[1:] {
+		if !bytes.Equal(pk, c.pks[0]) {
+			c.pk2 = pk
+			break
+		}
+	}
+	c.list = schnorr.TaggedHash("KeyAgg list", c.pks...)
+	var q *btcec.PublicKey
+	for i, pub := range pubs {
+		q = ec.Add(q, ec.Mul(pub, c.coef(c.pks[i]).Bytes()))
+	}
+	if q == nil {
+		return nil, fmt.Errorf("aggregate public key is infinity")
+	}
+	c.q = q
+	c.gacc = big.NewInt(1)
+	c.tacc = big.NewInt(0)
+	return c, nil
+}
+
+// coef returns the aggregation coefficient of the public key.
+func (c *KeyAggContext) coef(pk []byte) *big.Int {
+	if bytes.Equal(pk, c.pk2) {
+		return big.NewInt(1)
+	}
+	a := new(big.Int).SetBytes(schnorr.TaggedHash("KeyAgg coefficient", c.list, pk))
+	return a.Mod(a, curve.N)
+}
+
+// ApplyTweak adds the tweak to the aggregate public key.
+// If xonly, the tweak is added to the key of even y as BIP341.
+func (c *KeyAggContext) ApplyTweak(tweak []byte, xonly bool) error {
+	if len(tweak) != 32 {
+		return fmt.Errorf("illegal tweak size : %d", len(tweak))
+	}
+	t := new(big.Int).SetBytes(tweak)
+	if t.Cmp(curve.N) >= 0 {
+		return fmt.Errorf("tweak is over the order : %x", tweak)
+	}
+	q := c.q
+	g := big.NewInt(1)
+	if xonly && !schnorr.HasEvenY(q) {
+		q = ec.Neg(q)
+		g.Sub(curve.N, g)
+	}
+	q = ec.Add(q, ec.BaseMul(tweak))
+	if q == nil {
+		return fmt.Errorf("tweaked public key is infinity")
+	}
+	c.q = q
+	c.gacc = mod(new(big.Int).Mul(g, c.gacc))
+	c.tacc = mod(new(big.Int).Add(t, new(big.Int).Mul(g, c.tacc)))
+	return nil
+}
+
+// PublicKey returns the aggregate public key with the tweaks.
+func (c *KeyAggContext) PublicKey() *btcec.PublicKey {
+	return c.q
+}
+
+// SecNonce is the secret nonce, which must be used only once.
+type SecNonce struct {
+	k1  scalar.Scalar // first secret nonce
+	k2  scalar.Scalar // second secret nonce
+	pub []byte        // compressed public key of the signer
+}
+
+// Zero clears the secret nonce not to be used again.
+func (s *SecNonce) Zero() {
+	s.k1.Zero()
+	s.k2.Zero()
+}
+
+// NonceGen returns the secret nonce and the public nonce of the signer.
+// aggpk is the x-only aggregate public key, msg is the message to sign, and they can be nil.
+func NonceGen(pub *btcec.PublicKey, aggpk, msg []byte) (*SecNonce, []byte, error) {
+	rnd := make([]byte, 32)
+	_, err := rand.Read(rnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer scalar.Zero(rnd)
+	return nonceGen(rnd, nil, pub.SerializeCompressed(), aggpk, msg, nil)
+}
+
+// nonceGen returns the secret nonce and the public nonce of BIP327 NonceGen
+// from the random bytes, where sk, aggpk, msg and extra can be nil.
+func nonceGen(rnd, sk, pk, aggpk, msg, extra []byte) (*SecNonce, []byte, error) {
+	if sk != nil {
+		// rand = sk xor hash(rand')
+		rnd = schnorr.TaggedHash("MuSig/aux", rnd)
+		defer scalar.Zero(rnd)
+		ec.XorBytes(rnd, sk)
+	}
+	mp := []byte{0}
+	if msg != nil {
+		mp = make([]byte, 9)
+		mp[0] = 1
+		binary.BigEndian.PutUint64(mp[1:], uint64(len(msg)))
+		mp = append(mp, msg...)
+	}
+	ep := make([]byte, 4)
+	binary.BigEndian.PutUint32(ep, uint32(len(extra)))
+	ep = append(ep, extra...)
+	sec := &SecNonce{pub: pk}
+	pubnonce := []byte{}
+	for i, k := range []*scalar.Scalar{&sec.k1, &sec.k2} {
+		k.SetBytes(schnorr.TaggedHash("MuSig/nonce", rnd,
+			[]byte{byte(len(pk))}, pk, []byte{byte(len(aggpk))}, aggpk, mp,
+			ep, []byte{byte(i)}))
+		if k.IsZero() {
+			sec.Zero()
+			return nil, nil, fmt.Errorf("nonce is zero")
+		}
+		kb := k.Bytes()
+		pubnonce = append(pubnonce, ec.BaseMul(kb[:]).SerializeCompressed()...)
+		scalar.Zero(kb[:])
+	}
+	return sec, pubnonce, nil
+}
+
+// NonceAgg returns the aggregate nonce of the public nonces.
+func NonceAgg(pubnonces [][]byte) ([]byte, error) {
+	aggnonce := []byte{}
+	for j := 0; j < 2; j++ {
+		var r *btcec.PublicKey
+		for _, pubnonce := range pubnonces {
+			if len(pubnonce) != PubNonceSize {
+				return nil, fmt.Errorf("illegal public nonce size : %d", len(pubnonce))
+			}
+			p, err := ec.Parse(pubnonce[j*33 : (j+1)*33])
+			if err != nil {
+				return nil, err
+			}
+			r = ec.Add(r, p)
+		}
+		aggnonce = append(aggnonce, serializeExt(r)...)
+	}
+	return aggnonce, nil
+}
+
+// Session is the signing session of the message with the aggregate nonce.
+type Session struct {
+	ctx *KeyAggContext   // aggregate public key
+	msg []byte           // message to sign
+	b   *big.Int         // nonce coefficient
+	r   *btcec.PublicKey // final nonce
+	e   *big.Int         // challenge
+}
+
+// NewSession returns the signing session.
+func NewSession(ctx *KeyAggContext, aggnonce, msg []byte) (*Session, error) {
+	if len(aggnonce) != PubNonceSize {
+		return nil, fmt.Errorf("illegal aggregate nonce size : %d", len(aggnonce))
+	}
+	r1, err := parseExt(aggnonce[:33])
+	if err != nil {
+		return nil, err
+	}
+	r2, err := parseExt(aggnonce[33:])
+	if err != nil {
+		return nil, err
+	}
+	qx := schnorr.XOnly(ctx.q)
+	b := new(big.Int).SetBytes(schnorr.TaggedHash("MuSig/noncecoef", aggnonce, qx, msg))
+	b = mod(b)
+	r := ec.Add(r1, ec.Mul(r2, b.Bytes()))
+	if r == nil {
+		r = ec.BaseMul([]byte{1})
+	}
+	e := new(big.Int).SetBytes(schnorr.TaggedHash("BIP0340/challenge", schnorr.XOnly(r), qx, msg))
+	s := &Session{ctx, msg, b, r, mod(e)}
+	return s, nil
+}
+
+// Sign returns the partial signature by the private key with the secret nonce.
+// The secret nonce is cleared.
+func (s *Session) Sign(sec *SecNonce, pri *btcec.PrivateKey) ([]byte, error) {
+	defer sec.Zero()
+	if sec.k1.IsZero() || sec.k2.IsZero() {
+		return nil, fmt.Errorf("secret nonce is already used")
+	}
+	pub := (*btcec.PublicKey)(&pri.PublicKey)
+	pk := pub.SerializeCompressed()
+	if !bytes.Equal(pk, sec.pub) {
+		return nil, fmt.Errorf("public key mismatch secret nonce : %x", pk)
+	}
+	if !s.ctx.has(pk) {
+		return nil, fmt.Errorf("public key is not aggregated : %x", pk)
+	}
+	var k1, k2, d, b, ea scalar.Scalar
+	defer k1.Zero()
+	defer k2.Zero()
+	defer d.Zero()
+	k1, k2 = sec.k1, sec.k2
+	if !schnorr.HasEvenY(s.r) {
+		k1.Neg(&k1)
+		k2.Neg(&k2)
+	}
+	db := pri.Serialize()
+	d.SetBytes(db)
+	scalar.Zero(db)
+	// d = g * gacc * d
+	var g scalar.Scalar
+	g.SetBig(s.ctx.gacc)
+	if !schnorr.HasEvenY(s.ctx.q) {
+		g.Neg(&g)
+	}
+	d.Mul(&d, &g)
+	// s = k1 + b*k2 + e*a*d
+	b.SetBig(s.b)
+	ea.SetBig(mod(new(big.Int).Mul(s.e, s.ctx.coef(pk))))
+	k2.Mul(&k2, &b)
+	d.Mul(&d, &ea)
+	k1.Add(&k1, &k2)
+	k1.Add(&k1, &d)
+	psig := k1.Bytes()
+	return psig[:], nil
+}
+
+// Verify verifies the partial signature of the signer with the public nonce.
+func (s *Session) Verify(psig, pubnonce []byte, pub *btcec.PublicKey) error {
+	if len(psig) != PartialSigSize || new(big.Int).SetBytes(psig).Cmp(curve.N) >= 0 {
+		return fmt.Errorf("illegal partial signature : %x", psig)
+	}
+	if len(pubnonce) != PubNonceSize {
+		return fmt.Errorf("illegal public nonce size : %d", len(pubnonce))
+	}
+	pk := pub.SerializeCompressed()
+	if !s.ctx.has(pk) {
+		return fmt.Errorf("public key is not aggregated : %x", pk)
+	}
+	r1, err := ec.Parse(pubnonce[:33])
+	if err != nil {
+		return err
+	}
+	r2, err := ec.Parse(pubnonce[33:])
+	if err != nil {
+		return err
+	}
+	re := ec.Add(r1, ec.Mul(r2, s.b.Bytes()))
+	if !schnorr.HasEvenY(s.r) {
+		re = ec.Neg(re)
+	}
+	g := new(big.Int).Set(s.ctx.gacc)
+	if !schnorr.HasEvenY(s.ctx.q) {
+		g.Sub(curve.N, g)
+	}
+	// s*G = Re + e*a*g*P
+	eag := mod(new(big.Int).Mul(mod(new(big.Int).Mul(s.e, s.ctx.coef(pk))), g))
+	lhs := ec.BaseMul(psig)
+	rhs := ec.Add(re, ec.Mul(pub, eag.Bytes()))
+	if !ec.IsEqual(lhs, rhs) {
+		return fmt.Errorf("verify fail : %x", psig)
+	}
+	return nil
+}
+
+// Aggregate returns the BIP340 signature from the partial signatures.
+func (s *Session) Aggregate(psigs [][]byte) ([]byte, error) {
+	sum := new(big.Int)
+	for _, psig := range psigs {
+		v := new(big.Int).SetBytes(psig)
+		if len(psig) != PartialSigSize || v.Cmp(curve.N) >= 0 {
+			return nil, fmt.Errorf("illegal partial signature : %x", psig)
+		}
+		sum.Add(sum, v)
+	}
+	// s = sum + e*g*tacc
+	g := big.NewInt(1)
+	if !schnorr.HasEvenY(s.ctx.q) {
+		g.Sub(curve.N, g)
+	}
+	sum.Add(sum, new(big.Int).Mul(s.e, new(big.Int).Mul(g, s.ctx.tacc)))
+	sig := append(schnorr.XOnly(s.r), ec.Pad32(mod(sum).Bytes())...)
+	err := schnorr.Verify(s.ctx.q, s.msg, sig)
+	if err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// has returns true if the public key is aggregated.
+func (c *KeyAggContext) has(pk []byte) bool {
+	for _, p := range c.pks {
+		if bytes.Equal(p, pk) {
+			return true
+		}
+	}
+	return false
+}
+
+// serializeExt returns the compressed point, or 33 zero bytes for infinity.
+func serializeExt(p *btcec.PublicKey) []byte {
+	if p == nil {
+		return make([]byte, 33)
+	}
+	return p.SerializeCompressed()
+}
+
+// parseExt returns the point of the compressed bytes, or nil for 33 zero bytes.
+func parseExt(b []byte) (*btcec.PublicKey, error) {
+	if bytes.Equal(b, make([]byte, 33)) {
+		return nil, nil
+	}
+	return ec.Parse(b)
+}
+
+func mod(x *big.Int) *big.Int {
+	return x.Mod(x, curve.N)
+}
@@ -0,0 +1,308 @@
+package musig2
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+
+	"ec"
+	"schnorr"
+)
+
+// The test vectors are of BIP327.
+
+func fromHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func parsePubs(t *testing.T, strs []string, idxs []int) []*btcec.PublicKey {
+	t.Helper()
+	pubs := []*btcec.PublicKey{}
+	for _, i := range idxs {
+		pub, err := ec.Parse(fromHex(t, strs[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubs = append(pubs, pub)
+	}
+	return pubs
+}
+
+// parseSecNonce returns the secret nonce of 97 bytes serialized.
+func parseSecNonce(t *testing.T, s string) *SecNonce {
+	t.Helper()
+	b := fromHex(t, s)
+	sec := &SecNonce{pub: b[64:]}
+	sec.k1.SetBytes(b[:32])
+	sec.k2.SetBytes(b[32:64])
+	return sec
+}
+
+var keyAggPubs = []string{
+	"02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+	"03DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+	"023590A94E768F8E1815C2F24B4D80A8E3149316C3518CE7B7AD338368D038CA66",
+	// invalid public key
+	"020000000000000000000000000000000000000000000000000000000000000005",
+	// public key exceeds field size
+	"02FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC30",
+	// first byte of public key is not 2 or 3
+	"04F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+}
+
+func TestKeyAggVectors(t *testing.T) {
+	cases := []struct {
+		idxs     []int
+		expected string
+	}{
+		{[]int{0, 1, 2}, "90539EEDE565F5D054F32CC0C220126889ED1E5D193BAF15AEF344FE59D4610C"},
+		{[]int{2, 1, 0}, "6204DE8B083426DC6EAF9502D27024D53FC826BF7D2012148A0575435DF54B2B"},
+		{[]int{0, 0, 0}, "B436E3BAD62B8CD409969A224731C193D051162D8C5AE8B109306127DA3AA935"},
+		{[]int{0, 0, 1, 1}, "69BC22BFA5D106306E48A20679DE1D7389386124D07571D0D872686028C26A3E"},
+	}
+	for i, c := range cases {
+		ctx, err := KeyAgg(parsePubs(t, keyAggPubs, c.idxs))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(schnorr.XOnly(ctx.PublicKey()), fromHex(t, c.expected)) {
+			t.Fatalf("case %d : %x", i, schnorr.XOnly(ctx.PublicKey()))
+		}
+	}
+	for _, s := range keyAggPubs[3:] {
+		_, err := ec.Parse(fromHex(t, s))
+		if err == nil {
+			t.Fatalf("illegal public key is parsed : %s", s)
+		}
+	}
+	ctx, err := KeyAgg(parsePubs(t, keyAggPubs, []int{0, 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ctx.ApplyTweak(curve.N.Bytes(), false)
+	if err == nil {
+		t.Fatalf("tweak of the order is applied")
+	}
+	_, err = KeyAgg(nil)
+	if err == nil {
+		t.Fatalf("empty keys are aggregated")
+	}
+}
+
+func TestNonceGenVectors(t *testing.T) {
+	rnd := bytes.Repeat([]byte{0x0f}, 32)
+	sk := bytes.Repeat([]byte{0x02}, 32)
+	pk := "024D4B6CD1361032CA9BD2AEB9D900AA4D45D9EAD80AC9423374C451A7254D0766"
+	aggpk := bytes.Repeat([]byte{0x07}, 32)
+	extra := bytes.Repeat([]byte{0x08}, 32)
+	cases := []struct {
+		sk       []byte
+		pk       string
+		aggpk    []byte
+		msg      []byte
+		extra    []byte
+		expected string
+	}{
+		{sk, pk, aggpk, bytes.Repeat([]byte{0x01}, 32), extra,
+			"B114E502BEAA4E301DD08A50264172C84E41650E6CB726B410C0694D59EFFB6495B5CAF28D045B973D63E3C99A44B807BDE375FD6CB39E46DC4A511708D0E9D2024D4B6CD1361032CA9BD2AEB9D900AA4D45D9EAD80AC9423374C451A7254D0766"},
+		{sk, pk, aggpk, []byte{}, extra,
+			"E862B068500320088138468D47E0E6F147E01B6024244AE45EAC40ACE5929B9F0789E051170B9E705D0B9EB49049A323BBBBB206D8E05C19F46C6228742AA7A9024D4B6CD1361032CA9BD2AEB9D900AA4D45D9EAD80AC9423374C451A7254D0766"},
+		{sk, pk, aggpk, bytes.Repeat([]byte{0x26}, 38), extra,
+			"3221975ACBDEA6820EABF02A02B7F27D3A8EF68EE42787B88CBEFD9AA06AF3632EE85B1A61D8EF31126D4663A00DD96E9D1D4959E72D70FE5EBB6E7696EBA66F024D4B6CD1361032CA9BD2AEB9D900AA4D45D9EAD80AC9423374C451A7254D0766"},
+		{nil, "02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9", nil, nil, nil,
+			"89BDD787D0284E5E4D5FC572E49E316BAB7E21E3B1830DE37DFE80156FA41A6D0B17AE8D024C53679699A6FD7944D9C4A366B514BAF43088E0708B1023DD289702F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9"},
+	}
+	for i, c := range cases {
+		sec, pubnonce, err := nonceGen(rnd, c.sk, fromHex(t, c.pk), c.aggpk, c.msg, c.extra)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k1, k2 := sec.k1.Bytes(), sec.k2.Bytes()
+		got := append(append(k1[:], k2[:]...), sec.pub...)
+		if !bytes.Equal(got, fromHex(t, c.expected)) {
+			t.Fatalf("case %d : %x", i, got)
+		}
+		r1, r2 := baseMulBytes(k1[:]), baseMulBytes(k2[:])
+		if !bytes.Equal(pubnonce, append(r1, r2...)) {
+			t.Fatalf("case %d : public nonce %x", i, pubnonce)
+		}
+	}
+}
+
+func baseMulBytes(k []byte) []byte {
+	x, y := curve.ScalarBaseMult(k)
+	return (&btcec.PublicKey{Curve: curve, X: x, Y: y}).SerializeCompressed()
+}
+
+// signPubs are the public keys of the sign and verify vectors,
+// where the first is of signSk.
+var signPubs = []string{
+	"03935F972DA013F80AE011890FA89B67A27B7BE6CCB24D3274D18B2D4067F261A9",
+	"02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+	"02DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA661",
+}
+
+const (
+	signSk       = "7FB9E0E687ADA1EEBF7ECFE2F21E73EBDB51A7D450948DFE8D76D7F2D1007671"
+	signSecNonce = "508B81A611F100A6B2B6B29656590898AF488BCF2E1F55CF22E5CFB84421FE61FA27FD49B1D50085B481285E1CA205D55C82CC1B31FF5CD54A489829355901F703935F972DA013F80AE011890FA89B67A27B7BE6CCB24D3274D18B2D4067F261A9"
+	signMsg      = "F95466D086770E689964664219266FE5ED215C92AE20BAB5C9D79ADDDDF3C0CF"
+)
+
+var signPubNonces = []string{
+	"0337C87821AFD50A8644D820A8F3E02E499C931865C2360FB43D0A0D20DAFE07EA0287BF891D2A6DEAEBADC909352AA9405D1428C15F4B75F04DAE642A95C2548480",
+	"0279BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F817980279BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798",
+	"032DE2662628C90B03F5E720284EB52FF7D71F4284F627B68A853D78C78E1FFE9303E4C5524E83FFE1493B9077CF1CA6BEB2090C93D930321071AD40B2F44E599046",
+	// negation of the first, so that the aggregate nonce with it is infinity
+	"0237C87821AFD50A8644D820A8F3E02E499C931865C2360FB43D0A0D20DAFE07EA0387BF891D2A6DEAEBADC909352AA9405D1428C15F4B75F04DAE642A95C2548480",
+}
+
+var signAggNonces = []string{
+	"028465FCF0BBDBCF443AABCCE533D42B4B5A10966AC09A49655E8C42DAAB8FCD61037496A3CC86926D452CAFCFD55D25972CA1675D549310DE296BFF42F72EEEA8C9",
+	"000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+}
+
+// signSession returns the session of the keys and the nonces of the indices.
+func signSession(t *testing.T, pubs []string, keys, nonces []int, aggnonce string) *Session {
+	t.Helper()
+	ctx, err := KeyAgg(parsePubs(t, pubs, keys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newSignSession(t, ctx, nonces, aggnonce)
+}
+
+func newSignSession(t *testing.T, ctx *KeyAggContext, nonces []int, aggnonce string) *Session {
+	t.Helper()
+	pubnonces := [][]byte{}
+	for _, i := range nonces {
+		pubnonces = append(pubnonces, fromHex(t, signPubNonces[i]))
+	}
+	agg, err := NonceAgg(pubnonces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(agg, fromHex(t, aggnonce)) {
+		t.Fatalf("aggregate nonce : %x", agg)
+	}
+	s, err := NewSession(ctx, agg, fromHex(t, signMsg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSignVerifyVectors(t *testing.T) {
+	pri, _ := btcec.PrivKeyFromBytes(curve, fromHex(t, signSk))
+	cases := []struct {
+		keys, nonces []int
+		aggnonce     int
+		signer       int
+		expected     string
+	}{
+		{[]int{0, 1, 2}, []int{0, 1, 2}, 0, 0, "012ABBCB52B3016AC03AD82395A1A415C48B93DEF78718E62A7A90052FE224FB"},
+		{[]int{1, 0, 2}, []int{1, 0, 2}, 0, 1, "9FF2F7AAA856150CC8819254218D3ADEEB0535269051897724F9DB3789513A52"},
+		{[]int{1, 2, 0}, []int{1, 2, 0}, 0, 2, "FA23C359F6FAC4E7796BB93BC9F0532A95468C539BA20FF86D7C76ED92227900"},
+		// the aggregate nonce is infinity
+		{[]int{0, 1}, []int{0, 3}, 1, 0, "AE386064B26105404798F75DE2EB9AF5EDA5387B064B83D049CB7C5E08879531"},
+	}
+	pub := parsePubs(t, signPubs, []int{0})[0]
+	pubnonce := fromHex(t, signPubNonces[0])
+	for i, c := range cases {
+		s := signSession(t, signPubs, c.keys, c.nonces, signAggNonces[c.aggnonce])
+		psig, err := s.Sign(parseSecNonce(t, signSecNonce), pri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := fromHex(t, c.expected)
+		if !bytes.Equal(psig, expected) {
+			t.Fatalf("case %d : %x", i, psig)
+		}
+		err = s.Verify(psig, pubnonce, pub)
+		if err != nil {
+			t.Fatalf("case %d : %v", i, err)
+		}
+	}
+	// The verification fails for the negated signature, the wrong signer,
+	// and the signature exceeding the order.
+	s := signSession(t, signPubs, []int{0, 1, 2}, []int{0, 1, 2}, signAggNonces[0])
+	psig := fromHex(t, cases[0].expected)
+	neg := new(big.Int).Sub(curve.N, new(big.Int).SetBytes(psig))
+	if s.Verify(neg.Bytes(), pubnonce, pub) == nil {
+		t.Fatalf("negated partial signature is verified")
+	}
+	other := parsePubs(t, signPubs, []int{1})[0]
+	if s.Verify(psig, fromHex(t, signPubNonces[1]), other) == nil {
+		t.Fatalf("partial signature of wrong signer is verified")
+	}
+	if s.Verify(curve.N.Bytes(), pubnonce, pub) == nil {
+		t.Fatalf("partial signature exceeding the order is verified")
+	}
+	// The secret nonce is not reused.
+	sec := parseSecNonce(t, signSecNonce)
+	_, err := s.Sign(sec, pri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Sign(sec, pri)
+	if err == nil {
+		t.Fatalf("secret nonce is reused")
+	}
+}
+
+func TestTweakVectors(t *testing.T) {
+	pubs := []string{
+		"03935F972DA013F80AE011890FA89B67A27B7BE6CCB24D3274D18B2D4067F261A9",
+		"02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+		"02DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+	}
+	tweaks := []string{
+		"E8F791FF9225A2AF0102AFFF4A9A723D9612A682A25EBE79802B263CDFCD83BB",
+		"AE2EA797CC0FE72AC5B97B97F3C6957D7E4199A167A58EB08BCAFFDA70AC0455",
+		"F52ECBC565B3D8BEA2DFD5B75A4F457E54369809322E4120831626F290FA87E0",
+		"1969AD73CC177FA0B4FCED6DF1F7BF9907E665FDE9BA196A74FED0A3CF5AEF9D",
+	}
+	cases := []struct {
+		tweaks   []int
+		xonly    []bool
+		expected string
+	}{
+		{[]int{0}, []bool{true}, "E28A5C66E61E178C2BA19DB77B6CF9F7E2F0F56C17918CD13135E60CC848FE91"},
+		{[]int{0}, []bool{false}, "38B0767798252F21BF5702C48028B095428320F73A4B14DB1E25DE58543D2D2D"},
+		{[]int{0, 1}, []bool{false, true}, "408A0A21C4A0F5DACAF9646AD6EB6FECD7F7A11F03ED1F48DFFF2185BC2C2408"},
+		{[]int{0, 1, 2, 3}, []bool{false, false, true, true}, "45ABD206E61E3DF2EC9E264A6FEC8292141A633C28586388235541F9ADE75435"},
+		{[]int{0, 1, 2, 3}, []bool{true, false, true, false}, "B255FDCAC27B40C7CE7848E2D3B7BF5EA0ED756DA81565AC804CCCA3E1D5D239"},
+	}
+	pri, pub := btcec.PrivKeyFromBytes(curve, fromHex(t, signSk))
+	for i, c := range cases {
+		ctx, err := KeyAgg(parsePubs(t, pubs, []int{1, 2, 0}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j, k := range c.tweaks {
+			err = ctx.ApplyTweak(fromHex(t, tweaks[k]), c.xonly[j])
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		s := newSignSession(t, ctx, []int{1, 2, 0}, signAggNonces[0])
+		psig, err := s.Sign(parseSecNonce(t, signSecNonce), pri)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(psig, fromHex(t, c.expected)) {
+			t.Fatalf("case %d : %x", i, psig)
+		}
+		err = s.Verify(psig, fromHex(t, signPubNonces[0]), (*btcec.PublicKey)(pub))
+		if err != nil {
+			t.Fatalf("case %d : %v", i, err)
+		}
+	}
+}
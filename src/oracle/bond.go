@@ -0,0 +1,170 @@
+// Package oracle project bond.go
+package oracle
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+
+	"ec"
+)
+
+// Bond is the fidelity bond dataset.
+type Bond struct {
+	Txid     string `json:"txid"`     // txid of bond
+	Vout     uint32 `json:"vout"`     // output index of bond
+	Amount   int64  `json:"amount"`   // amount of bond (satoshi)
+	Locktime uint32 `json:"locktime"` // locktime of bond
+	Pubkey   string `json:"pubkey"`   // public key of bond
+	Sign     string `json:"sign"`     // signature of bond key for oracle public key of event
+}
+
+// BondAddress returns a bech32 address of the bond locked until locktime.
+func (oracle *Oracle) BondAddress(locktime uint32) (string, error) {
+	pub, err := oracle.signer.BondPublicKey()
+	if err != nil {
+		return "", err
+	}
+	script := BondScript(pub, locktime)
+	adr, err := btcutil.NewAddressWitnessScriptHash(chainhash.HashB(script), &oracle.params)
+	if err != nil {
+		return "", err
+	}
+	return adr.EncodeAddress(), nil
+}
+
+// BondPkScript returns a pkScript of the bond locked until locktime.
+func (oracle *Oracle) BondPkScript(locktime uint32) ([]byte, error) {
+	pub, err := oracle.signer.BondPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return bondPkScript(BondScript(pub, locktime)), nil
+}
+
+// SetBond sets the utxo of the bond.
+func (oracle *Oracle) SetBond(txid string, vout uint32, locktime uint32) error {
+	res, err := oracle.rpc.Request("gettxout", txid, vout, true)
+	if err != nil {
+		return err
+	}
+	txout := &TxOutResult{}
+	err = res.UnmarshalResult(txout)
+	if err != nil {
+		return err
+	}
+	pub, err := oracle.signer.BondPublicKey()
+	if err != nil {
+		return err
+	}
+	pkScript := bondPkScript(BondScript(pub, locktime))
+	if txout.ScriptPubKey.Hex != hex.EncodeToString(pkScript) {
+		return fmt.Errorf("illegal bond pkScript : %s", txout.ScriptPubKey.Hex)
+	}
+	amt, err := btcutil.NewAmount(txout.Value)
+	if err != nil {
+		return err
+	}
+	bond := &Bond{}
+	bond.Txid = txid
+	bond.Vout = vout
+	bond.Amount = int64(amt)
+	bond.Locktime = locktime
+	bond.Pubkey = hex.EncodeToString(pub.SerializeCompressed())
+	oracle.bond = bond
+	return nil
+}
+
+// signBond returns the bond signed for oracle public key.
+func (oracle *Oracle) signBond(opub *btcec.PublicKey) (*Bond, error) {
+	if oracle.bond == nil {
+		return nil, nil
+	}
+	bond := *oracle.bond
+	sign, err := oracle.signer.SignBond(opub, bond.Txid, bond.Vout, bond.Locktime)
+	if err != nil {
+		return nil, err
+	}
+	bond.Sign = hex.EncodeToString(sign)
+	return &bond, nil
+}
+
+// TxOutResult is the result of gettxout.
+type TxOutResult struct {
+	Confirmations int64   `json:"confirmations"`
+	Value         float64 `json:"value"`
+	ScriptPubKey  struct {
+		Hex string `json:"hex"`
+	} `json:"scriptPubKey"`
+}
+
+// VerifyBond verifies the signature of bond and returns the pkScript of bond.
+func VerifyBond(opub *btcec.PublicKey, bond *Bond) ([]byte, error) {
+	bs, err := hex.DecodeString(bond.Pubkey)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := ec.Parse(bs)
+	if err != nil {
+		return nil, err
+	}
+	bs, err = hex.DecodeString(bond.Sign)
+	if err != nil {
+		return nil, err
+	}
+	sign, err := btcec.ParseDERSignature(bs, btcec.S256())
+	if err != nil {
+		return nil, err
+	}
+	if !sign.Verify(BondHash(opub, bond), pub) {
+		return nil, fmt.Errorf("illegal bond sign")
+	}
+	return bondPkScript(BondScript(pub, bond.Locktime)), nil
+}
+
+// BondHash returns the hash which binds the bond to oracle public key.
+func BondHash(opub *btcec.PublicKey, bond *Bond) []byte {
+	s := sha256.New()
+	s.Write([]byte("dlc/oracle/bond"))
+	s.Write(opub.SerializeCompressed())
+	s.Write([]byte(bond.Txid))
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, bond.Vout)
+	s.Write(b)
+	binary.LittleEndian.PutUint32(b, bond.Locktime)
+	s.Write(b)
+	return s.Sum(nil)
+}
+
+// BondScript returns bond script.
+func BondScript(pub *btcec.PublicKey, locktime uint32) []byte {
+	// bond script:
+	// <locktime>
+	// OP_CHECKLOCKTIMEVERIFY
+	// OP_DROP
+	// <public key>
+	// OP_CHECKSIG
+	builder := txscript.NewScriptBuilder()
+	builder.AddInt64(int64(locktime))
+	builder.AddOp(txscript.OP_CHECKLOCKTIMEVERIFY)
+	builder.AddOp(txscript.OP_DROP)
+	builder.AddData(pub.SerializeCompressed())
+	builder.AddOp(txscript.OP_CHECKSIG)
+	script, _ := builder.Script()
+	return script
+}
+
+func bondPkScript(script []byte) []byte {
+	// P2WSH is OP_0 + SHA256(script)
+	builder := txscript.NewScriptBuilder()
+	builder.AddOp(txscript.OP_0)
+	builder.AddData(chainhash.HashB(script))
+	pkScript, _ := builder.Script()
+	return pkScript
+}
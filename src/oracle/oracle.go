@@ -6,56 +6,53 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
 	"math/big"
 
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
-	"github.com/btcsuite/btcutil/hdkeychain"
 
 	"rpc"
 )
 
+// CancelMessage is the message signed by the oracle when the event is cancelled.
+var CancelMessage = []byte("cancelled")
+
 // Oracle is the oracle dataset.
 type Oracle struct {
-	name   string                  // oracle name
-	rpc    *rpc.BtcRPC             // bitcoin rpc
-	extKey *hdkeychain.ExtendedKey // oracle extendedkey
-	params chaincfg.Params         // bitcoin network
+	name   string          // oracle name
+	rpc    *rpc.BtcRPC     // bitcoin rpc
+	signer Signer          // oracle key signer
+	params chaincfg.Params // bitcoin network
+	bond   *Bond           // fidelity bond
 }
 
-// NewOracle returns a new Oracle.
+// NewOracle returns a new Oracle with the key in this process.
 func NewOracle(name string, params chaincfg.Params, rpc *rpc.BtcRPC) (*Oracle, error) {
-	oracle := new(Oracle)
-	oracle.name = name
-	oracle.params = params
-	oracle.rpc = rpc
 	// TODO
-	seed := chainhash.DoubleHashB([]byte(oracle.name))
-	mExtKey, err := hdkeychain.NewMaster(seed, &params)
+	seed := chainhash.DoubleHashB([]byte(name))
+	signer, err := NewLocalSigner(seed, params)
 	if err != nil {
-		log.Printf("hdkeychain.NewMaster error : %v", err)
 		return nil, err
 	}
-	key := mExtKey
-	// TODO m/1/2/3/4/5
-	path := []uint32{1, 2, 3, 4, 5}
-	for _, i := range path {
-		key, err = key.Child(i)
-		if err != nil {
-			log.Printf("key.Child error : %v", err)
-			return nil, err
-		}
-	}
-	oracle.extKey = key
-	return oracle, nil
+	return NewOracleWithSigner(name, params, rpc, signer), nil
+}
+
+// NewOracleWithSigner returns a new Oracle with the signer.
+func NewOracleWithSigner(name string, params chaincfg.Params, rpc *rpc.BtcRPC, signer Signer) *Oracle {
+	oracle := new(Oracle)
+	oracle.name = name
+	oracle.params = params
+	oracle.rpc = rpc
+	oracle.signer = signer
+	return oracle
 }
 
 // Keys is the keys dataset.
 type Keys struct {
 	Pubkey string   `json:"pubkey"`
 	Keys   []string `json:"keys"`
+	Bond   *Bond    `json:"bond,omitempty"` // fidelity bond (option)
 }
 
 // Keys returns the keys data.
@@ -63,22 +60,29 @@ func (oracle *Oracle) Keys(height int) ([]byte, error) {
 	if height < 0 {
 		return nil, fmt.Errorf("invalid params height:%d", height)
 	}
-	_, pub, _ := oracle.getKeys(height)
+	pub, pubs, err := oracle.signer.PublicKeys(height, chainhash.HashSize)
+	if err != nil {
+		return nil, err
+	}
 	keys := []string{}
-	for i := 0; i < chainhash.HashSize; i++ {
-		_, key, _ := oracle.getKeys(height, i)
+	for _, key := range pubs {
 		keys = append(keys, hex.EncodeToString(key.SerializeCompressed()))
 	}
-	okeys := &Keys{hex.EncodeToString(pub.SerializeCompressed()), keys}
+	bond, err := oracle.signBond(pub)
+	if err != nil {
+		return nil, err
+	}
+	okeys := &Keys{hex.EncodeToString(pub.SerializeCompressed()), keys, bond}
 	bs, _ := json.Marshal(okeys)
 	return bs, nil
 }
 
 // Signs is signatures data format.
 type Signs struct {
-	Hash  string   `json:"hash"`
-	Msgs  []string `json:"msgs"`
-	Signs []string `json:"signs"`
+	Hash   string   `json:"hash"`
+	Msgs   []string `json:"msgs"`
+	Signs  []string `json:"signs"`
+	Cancel bool     `json:"cancel,omitempty"` // the event is cancelled
 }
 
 // Signs returns the signatures data.
@@ -101,46 +105,51 @@ func (oracle *Oracle) Signs(height int) ([]byte, error) {
 	}
 	result, _ := res.Result.(string)
 	hash, _ := chainhash.NewHashFromStr(result)
-	pri, _, _ := oracle.getKeys(height)
-	o := pri.D
-	msgs := []string{}
-	sigs := []string{}
+	msgs := [][]byte{}
 	for i := 0; i < chainhash.HashSize; i++ {
-		key, _, _ := oracle.getKeys(height, i)
-		r := key.D
-		R := key.PubKey()
-		m := []byte{hash[i]}
-		// s = r - H(R,m)o
-		// ho = H(R,m) * o
-		ho := new(big.Int).Mul(H(R, m), o)
-		// s = r - ho
-		s := new(big.Int).Mod(new(big.Int).Sub(r, ho), btcec.S256().N)
-		sigs = append(sigs, hex.EncodeToString(s.Bytes()))
-		msgs = append(msgs, hex.EncodeToString(m))
-	}
-	osigs := &Signs{hash.String(), msgs, sigs}
+		msgs = append(msgs, []byte{hash[i]})
+	}
+	osigs, err := oracle.sign(height, msgs)
+	if err != nil {
+		return nil, err
+	}
+	osigs.Hash = hash.String()
 	bs, _ := json.Marshal(osigs)
 	return bs, nil
 }
 
-func (oracle *Oracle) getKeys(path ...int) (*btcec.PrivateKey, *btcec.PublicKey, error) {
-	key := oracle.extKey
-	var err error
-	for _, i := range path {
-		key, err = key.Child(uint32(i))
-		if err != nil {
-			return nil, nil, err
-		}
+// Cancel returns the signatures data of the cancelled event.
+// The cancel message is signed with every key of the event,
+// so the event can no longer be signed with the block hash.
+func (oracle *Oracle) Cancel(height int) ([]byte, error) {
+	if height < 0 {
+		return nil, fmt.Errorf("invalid params height:%d", height)
+	}
+	msgs := [][]byte{}
+	for i := 0; i < chainhash.HashSize; i++ {
+		msgs = append(msgs, CancelMessage)
 	}
-	prvKey, err := key.ECPrivKey()
+	osigs, err := oracle.sign(height, msgs)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	pubKey, err := key.ECPubKey()
+	osigs.Cancel = true
+	bs, _ := json.Marshal(osigs)
+	return bs, nil
+}
+
+func (oracle *Oracle) sign(height int, msgs [][]byte) (*Signs, error) {
+	signs, err := oracle.signer.Attest(height, msgs)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	return prvKey, pubKey, nil
+	hmsgs := []string{}
+	sigs := []string{}
+	for i, m := range msgs {
+		sigs = append(sigs, hex.EncodeToString(signs[i].Bytes()))
+		hmsgs = append(hmsgs, hex.EncodeToString(m))
+	}
+	return &Signs{Msgs: hmsgs, Signs: sigs}, nil
 }
 
 // Commit returns a message publickey.
@@ -158,6 +167,14 @@ func Commit(R, O *btcec.PublicKey, m []byte) *btcec.PublicKey {
 	return P
 }
 
+// Verify verifies the signature s of message m for contract key R.
+func Verify(R, O *btcec.PublicKey, m []byte, s *big.Int) bool {
+	// sG = R - H(R,m)O
+	sG := new(btcec.PublicKey)
+	sG.X, sG.Y = btcec.S256().ScalarBaseMult(s.Bytes())
+	return Commit(R, O, m).IsEqual(sG)
+}
+
 // H is a hash function.
 func H(R *btcec.PublicKey, m []byte) *big.Int {
 	s := sha256.New()
@@ -0,0 +1,530 @@
+// Package oracle project signer.go
+package oracle
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcutil/hdkeychain"
+
+	"ec"
+	"scalar"
+)
+
+// MaxEventKeys is the maximum number of contract keys of an event.
+const MaxEventKeys = chainhash.HashSize
+
+// Signer holds the oracle key and signs the events.
+type Signer interface {
+	// PublicKeys returns the oracle public key and n contract keys of the event.
+	PublicKeys(height, n int) (*btcec.PublicKey, []*btcec.PublicKey, error)
+	// Attest returns the signatures of the messages of the event.
+	// An event is signed only with one set of messages.
+	Attest(height int, msgs [][]byte) ([]*big.Int, error)
+	// BondPublicKey returns the public key of bond.
+	BondPublicKey() (*btcec.PublicKey, error)
+	// SignBond returns the signature of bond key which binds the bond utxo
+	// to oracle public key. The signed hash is computed by signer.
+	SignBond(opub *btcec.PublicKey, txid string, vout, locktime uint32) ([]byte, error)
+}
+
+// LocalSigner is the signer holding the key in this process.
+type LocalSigner struct {
+	mu       sync.Mutex
+	extKey   *hdkeychain.ExtendedKey // oracle extendedkey
+	attested map[int][][]byte        // attested messages of events
+	state    string                  // file path of attested messages
+}
+
+// NewLocalSigner returns a new LocalSigner.
+func NewLocalSigner(seed []byte, params chaincfg.Params) (*LocalSigner, error) {
+	signer := &LocalSigner{}
+	signer.attested = map[int][][]byte{}
+	mExtKey, err := hdkeychain.NewMaster(seed, &params)
+	if err != nil {
+		log.Printf("hdkeychain.NewMaster error : %v", err)
+		return nil, err
+	}
+	key := mExtKey
+	// TODO m/1/2/3/4/5
+	path := []uint32{1, 2, 3, 4, 5}
+	for _, i := range path {
+		key, err = key.Child(i)
+		if err != nil {
+			log.Printf("key.Child error : %v", err)
+			return nil, err
+		}
+	}
+	signer.extKey = key
+	return signer, nil
+}
+
+// SetStateFile loads the attested messages from file,
+// and the messages are saved to file when attested.
+func (s *LocalSigner) SetStateFile(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bs, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		attested := map[string][]string{}
+		err = json.Unmarshal(bs, &attested)
+		if err != nil {
+			return err
+		}
+		for h, ms := range attested {
+			height, err := strconv.Atoi(h)
+			if err != nil {
+				return err
+			}
+			msgs, err := strsToBss(ms)
+			if err != nil {
+				return err
+			}
+			s.attested[height] = msgs
+		}
+	}
+	s.state = path
+	return nil
+}
+
+// PublicKeys returns the oracle public key and n contract keys of the event.
+func (s *LocalSigner) PublicKeys(height, n int) (*btcec.PublicKey, []*btcec.PublicKey, error) {
+	if n < 0 || n > MaxEventKeys {
+		return nil, nil, fmt.Errorf("illegal keys size : %d", n)
+	}
+	_, pub, err := s.getKeys(height)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := []*btcec.PublicKey{}
+	for i := 0; i < n; i++ {
+		_, key, err := s.getKeys(height, i)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+	}
+	return pub, keys, nil
+}
+
+// Attest returns the signatures of the messages of the event.
+func (s *LocalSigner) Attest(height int, msgs [][]byte) ([]*big.Int, error) {
+	if len(msgs) > MaxEventKeys {
+		return nil, fmt.Errorf("illegal msgs size : %d", len(msgs))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attested, ok := s.attested[height]
+	if ok && !reflect.DeepEqual(attested, msgs) {
+		return nil, fmt.Errorf("event is already attested height:%d", height)
+	}
+	pri, _, err := s.getKeys(height)
+	if err != nil {
+		return nil, err
+	}
+	var o, r, ho, sig scalar.Scalar
+	setPrivKey(&o, pri)
+	defer o.Zero()
+	defer r.Zero()
+	defer ho.Zero()
+	sigs := []*big.Int{}
+	for i, m := range msgs {
+		key, _, err := s.getKeys(height, i)
+		if err != nil {
+			return nil, err
+		}
+		setPrivKey(&r, key)
+		R := key.PubKey()
+		// s = r - H(R,m)o
+		// ho = H(R,m) * o
+		ho.SetBig(H(R, m))
+		ho.Mul(&ho, &o)
+		// s = r - ho
+		sig.Sub(&r, &ho)
+		sigs = append(sigs, sig.Big())
+	}
+	if !ok {
+		s.attested[height] = msgs
+		err = s.save()
+		if err != nil {
+			delete(s.attested, height)
+			return nil, err
+		}
+	}
+	return sigs, nil
+}
+
+// BondPublicKey returns the public key of bond.
+func (s *LocalSigner) BondPublicKey() (*btcec.PublicKey, error) {
+	pri, err := s.bondKey()
+	if err != nil {
+		return nil, err
+	}
+	return pri.PubKey(), nil
+}
+
+// SignBond returns the signature of bond key which binds the bond utxo
+// to oracle public key.
+// Only BondHash is signed, so the bond key never signs a transaction.
+func (s *LocalSigner) SignBond(opub *btcec.PublicKey, txid string, vout, locktime uint32) ([]byte, error) {
+	if opub == nil {
+		return nil, fmt.Errorf("oracle public key is nil")
+	}
+	_, err := chainhash.NewHashFromStr(txid)
+	if err != nil || len(txid) != chainhash.MaxHashStringSize {
+		return nil, fmt.Errorf("illegal bond txid : %s", txid)
+	}
+	bond := &Bond{Txid: txid, Vout: vout, Locktime: locktime}
+	pri, err := s.bondKey()
+	if err != nil {
+		return nil, err
+	}
+	sign, err := pri.Sign(BondHash(opub, bond))
+	if err != nil {
+		return nil, err
+	}
+	return sign.Serialize(), nil
+}
+
+func (s *LocalSigner) bondKey() (*btcec.PrivateKey, error) {
+	key, err := s.extKey.Child(hdkeychain.HardenedKeyStart)
+	if err != nil {
+		return nil, err
+	}
+	return key.ECPrivKey()
+}
+
+func (s *LocalSigner) getKeys(path ...int) (*btcec.PrivateKey, *btcec.PublicKey, error) {
+	key := s.extKey
+	var err error
+	for _, i := range path {
+		key, err = key.Child(uint32(i))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	prvKey, err := key.ECPrivKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	return prvKey, pubKey, nil
+}
+
+// setPrivKey sets the private key to x and clears the private key.
+func setPrivKey(x *scalar.Scalar, pri *btcec.PrivateKey) {
+	b := pri.Serialize()
+	x.SetBytes(b)
+	scalar.Zero(b)
+	scalar.ZeroBig(pri.D)
+}
+
+func (s *LocalSigner) save() error {
+	if s.state == "" {
+		return nil
+	}
+	attested := map[string][]string{}
+	for height, msgs := range s.attested {
+		attested[strconv.Itoa(height)] = bssToStrs(msgs)
+	}
+	bs, _ := json.Marshal(attested)
+	tmp := s.state + ".tmp"
+	err := ioutil.WriteFile(tmp, bs, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.state)
+}
+
+// Signer protocol
+// The request and the response are a JSON object per line.
+
+// SignerRequest is the request of signer protocol.
+type SignerRequest struct {
+	Method   string   `json:"method"`             // keys, attest, bondkey or signbond
+	Height   int      `json:"height,omitempty"`   // height of event
+	Size     int      `json:"size,omitempty"`     // number of contract keys
+	Msgs     []string `json:"msgs,omitempty"`     // messages to attest
+	Pubkey   string   `json:"pubkey,omitempty"`   // oracle public key bound to bond
+	Txid     string   `json:"txid,omitempty"`     // txid of bond
+	Vout     uint32   `json:"vout,omitempty"`     // output index of bond
+	Locktime uint32   `json:"locktime,omitempty"` // locktime of bond
+}
+
+// SignerResponse is the response of signer protocol.
+type SignerResponse struct {
+	Pubkey string   `json:"pubkey,omitempty"` // oracle or bond public key
+	Keys   []string `json:"keys,omitempty"`   // contract keys
+	Signs  []string `json:"signs,omitempty"`  // signatures of messages
+	Sign   string   `json:"sign,omitempty"`   // signature by bond key
+	Error  string   `json:"error,omitempty"`  // error message
+}
+
+// ServeSigner serves signer protocol until r is closed.
+func ServeSigner(s Signer, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0x10000), 0x100000)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		var req SignerRequest
+		res := &SignerResponse{}
+		err := json.Unmarshal(scanner.Bytes(), &req)
+		if err == nil {
+			err = serve(s, &req, res)
+		}
+		if err != nil {
+			res = &SignerResponse{Error: err.Error()}
+		}
+		err = enc.Encode(res)
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func serve(s Signer, req *SignerRequest, res *SignerResponse) error {
+	switch req.Method {
+	case "keys":
+		pub, keys, err := s.PublicKeys(req.Height, req.Size)
+		if err != nil {
+			return err
+		}
+		res.Pubkey = hex.EncodeToString(pub.SerializeCompressed())
+		for _, key := range keys {
+			res.Keys = append(res.Keys, hex.EncodeToString(key.SerializeCompressed()))
+		}
+	case "attest":
+		msgs, err := strsToBss(req.Msgs)
+		if err != nil {
+			return err
+		}
+		signs, err := s.Attest(req.Height, msgs)
+		if err != nil {
+			return err
+		}
+		for _, sign := range signs {
+			res.Signs = append(res.Signs, hex.EncodeToString(sign.Bytes()))
+		}
+	case "bondkey":
+		pub, err := s.BondPublicKey()
+		if err != nil {
+			return err
+		}
+		res.Pubkey = hex.EncodeToString(pub.SerializeCompressed())
+	case "signbond":
+		opub, err := strToPub(req.Pubkey)
+		if err != nil {
+			return err
+		}
+		sign, err := s.SignBond(opub, req.Txid, req.Vout, req.Locktime)
+		if err != nil {
+			return err
+		}
+		res.Sign = hex.EncodeToString(sign)
+	default:
+		return fmt.Errorf("unknown method : %s", req.Method)
+	}
+	return nil
+}
+
+// RemoteSigner is the signer in other process.
+type RemoteSigner struct {
+	mu   sync.Mutex
+	conn io.ReadWriteCloser
+	r    *bufio.Reader
+}
+
+// NewRemoteSigner returns a new RemoteSigner talking over conn.
+func NewRemoteSigner(conn io.ReadWriteCloser) *RemoteSigner {
+	return &RemoteSigner{conn: conn, r: bufio.NewReader(conn)}
+}
+
+// DialSigner returns a new RemoteSigner connected to unix socket.
+func DialSigner(path string) (*RemoteSigner, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return NewRemoteSigner(conn), nil
+}
+
+// StartSigner starts the signer process and returns a new RemoteSigner
+// talking over stdin and stdout of the process.
+func StartSigner(name string, args ...string) (*RemoteSigner, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = os.Stderr
+	w, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	r, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	return NewRemoteSigner(&pipe{r, w, cmd}), nil
+}
+
+type pipe struct {
+	io.ReadCloser
+	w   io.WriteCloser
+	cmd *exec.Cmd
+}
+
+func (p *pipe) Write(b []byte) (int, error) {
+	return p.w.Write(b)
+}
+
+func (p *pipe) Close() error {
+	err := p.w.Close()
+	if err != nil {
+		return err
+	}
+	return p.cmd.Wait()
+}
+
+// Close closes the connection to signer.
+func (s *RemoteSigner) Close() error {
+	return s.conn.Close()
+}
+
+// PublicKeys returns the oracle public key and n contract keys of the event.
+func (s *RemoteSigner) PublicKeys(height, n int) (*btcec.PublicKey, []*btcec.PublicKey, error) {
+	res, err := s.request(&SignerRequest{Method: "keys", Height: height, Size: n})
+	if err != nil {
+		return nil, nil, err
+	}
+	pub, err := strToPub(res.Pubkey)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(res.Keys) != n {
+		return nil, nil, fmt.Errorf("illegal keys size : %d, %d", len(res.Keys), n)
+	}
+	keys := []*btcec.PublicKey{}
+	for _, str := range res.Keys {
+		key, err := strToPub(str)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+	}
+	return pub, keys, nil
+}
+
+// Attest returns the signatures of the messages of the event.
+func (s *RemoteSigner) Attest(height int, msgs [][]byte) ([]*big.Int, error) {
+	res, err := s.request(&SignerRequest{Method: "attest", Height: height, Msgs: bssToStrs(msgs)})
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Signs) != len(msgs) {
+		return nil, fmt.Errorf("illegal signs size : %d, %d", len(res.Signs), len(msgs))
+	}
+	signs := []*big.Int{}
+	for _, str := range res.Signs {
+		bs, err := hex.DecodeString(str)
+		if err != nil {
+			return nil, err
+		}
+		signs = append(signs, new(big.Int).SetBytes(bs))
+	}
+	return signs, nil
+}
+
+// BondPublicKey returns the public key of bond.
+func (s *RemoteSigner) BondPublicKey() (*btcec.PublicKey, error) {
+	res, err := s.request(&SignerRequest{Method: "bondkey"})
+	if err != nil {
+		return nil, err
+	}
+	return strToPub(res.Pubkey)
+}
+
+// SignBond returns the signature of bond key which binds the bond utxo
+// to oracle public key.
+func (s *RemoteSigner) SignBond(opub *btcec.PublicKey, txid string, vout, locktime uint32) ([]byte, error) {
+	req := &SignerRequest{Method: "signbond", Txid: txid, Vout: vout, Locktime: locktime}
+	req.Pubkey = hex.EncodeToString(opub.SerializeCompressed())
+	res, err := s.request(req)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(res.Sign)
+}
+
+func (s *RemoteSigner) request(req *SignerRequest) (*SignerResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bs, _ := json.Marshal(req)
+	_, err := s.conn.Write(append(bs, '\n'))
+	if err != nil {
+		return nil, err
+	}
+	line, err := s.r.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	res := &SignerResponse{}
+	err = json.Unmarshal(line, res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != "" {
+		return nil, fmt.Errorf("signer error : %s", res.Error)
+	}
+	return res, nil
+}
+
+func strToPub(str string) (*btcec.PublicKey, error) {
+	bs, err := hex.DecodeString(str)
+	if err != nil {
+		return nil, err
+	}
+	return ec.Parse(bs)
+}
+
+func bssToStrs(bss [][]byte) []string {
+	strs := []string{}
+	for _, bs := range bss {
+		strs = append(strs, hex.EncodeToString(bs))
+	}
+	return strs
+}
+
+func strsToBss(strs []string) ([][]byte, error) {
+	bss := [][]byte{}
+	for _, str := range strs {
+		bs, err := hex.DecodeString(str)
+		if err != nil {
+			return nil, err
+		}
+		bss = append(bss, bs)
+	}
+	return bss, nil
+}
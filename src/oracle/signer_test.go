@@ -0,0 +1,69 @@
+package oracle
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// The signatures were made by big.Int arithmetic before Scalar was used.
+var attestVectors = []struct {
+	msg  []byte
+	sign string
+}{
+	{[]byte{0x00}, "c395cfc0ac47e41c99b5fd857cda3395412c369612b3740d75b4d7562e4b751f"},
+	{[]byte{0x7f}, "4570a4b918582b27a44a8b9de2af66c54e7f8bdc4f917df95c10e44994e533f6"},
+	{[]byte{0xff}, "4d1c44def2e6878a8c9b5087e0f310e5538679d017e4ce3cf5a4f3c0302d60aa"},
+	{CancelMessage, "51577a51ff5aef04be4df5b174bfd9981f8875aa88f82dc3a9fbea44fc0008b5"},
+}
+
+func newTestSigner(t *testing.T) *LocalSigner {
+	seed := chainhash.DoubleHashB([]byte("Olivia"))
+	signer, err := NewLocalSigner(seed, chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestAttestVectors(t *testing.T) {
+	signer := newTestSigner(t)
+	height := 100
+	msgs := [][]byte{}
+	for _, v := range attestVectors {
+		msgs = append(msgs, v.msg)
+	}
+	pub, keys, err := signer.PublicKeys(height, len(msgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(pub.SerializeCompressed()) !=
+		"02d78732ae1cbcdf08f729c9710235d17dcea778d0389580a91eee8c1d856c56eb" {
+		t.Fatalf("oracle public key : %x", pub.SerializeCompressed())
+	}
+	signs, err := signer.Attest(height, msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range attestVectors {
+		want, _ := new(big.Int).SetString(v.sign, 16)
+		if signs[i].Cmp(want) != 0 {
+			t.Fatalf("sign %d : got %x, want %s", i, signs[i], v.sign)
+		}
+		if !Verify(keys[i], pub, v.msg, signs[i]) {
+			t.Fatalf("verify %d", i)
+		}
+	}
+	// The same messages are signed again, but others are not.
+	_, err = signer.Attest(height, msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = signer.Attest(height, msgs[:1])
+	if err == nil {
+		t.Fatalf("attested twice")
+	}
+}
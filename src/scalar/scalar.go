@@ -0,0 +1,226 @@
+// Package scalar project scalar.go
+package scalar
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// Scalar is an integer modulo the group order N of secp256k1.
+// The words are little endian and the operations run in constant time.
+type Scalar [4]uint64
+
+// n is the group order N.
+var n = [4]uint64{0xBFD25E8CD0364141, 0xBAAEDCE6AF48A03B, 0xFFFFFFFFFFFFFFFE, 0xFFFFFFFFFFFFFFFF}
+
+// nBig is the group order N.
+var nBig, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+
+// c is 2^256 - N.
+var c = [3]uint64{0x402DA1732FC9BEBF, 0x4551231950B75FC4, 0x0000000000000001}
+
+// SetBytes sets s to the big endian bytes b modulo N and returns s.
+// b must not be longer than 32 bytes.
+func (s *Scalar) SetBytes(b []byte) *Scalar {
+	var buf [32]byte
+	copy(buf[32-len(b):], b)
+	var x [5]uint64
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			x[i] |= uint64(buf[31-i*8-j]) << uint(j*8)
+		}
+	}
+	Zero(buf[:])
+	*s = subN(x)
+	return s
+}
+
+// SetBig sets s to x modulo N and returns s.
+// It is not constant time and is used for public values,
+// so secrets are set by SetBytes.
+func (s *Scalar) SetBig(x *big.Int) *Scalar {
+	m := new(big.Int).Mod(x, nBig)
+	b := m.Bytes()
+	s.SetBytes(b)
+	Zero(b)
+	ZeroBig(m)
+	return s
+}
+
+// Bytes returns the 32 bytes big endian of s.
+func (s *Scalar) Bytes() [32]byte {
+	return bytesOf(*s)
+}
+
+// Big returns a new big.Int of s.
+func (s *Scalar) Big() *big.Int {
+	b := s.Bytes()
+	x := new(big.Int).SetBytes(b[:])
+	Zero(b[:])
+	return x
+}
+
+// Add sets s to a + b and returns s.
+func (s *Scalar) Add(a, b *Scalar) *Scalar {
+	var x [5]uint64
+	var carry uint64
+	for i := 0; i < 4; i++ {
+		x[i], carry = bits.Add64(a[i], b[i], carry)
+	}
+	x[4] = carry
+	*s = subN(x)
+	return s
+}
+
+// Neg sets s to -a and returns s.
+func (s *Scalar) Neg(a *Scalar) *Scalar {
+	var x Scalar
+	var borrow uint64
+	for i := 0; i < 4; i++ {
+		x[i], borrow = bits.Sub64(n[i], a[i], borrow)
+	}
+	// -0 is 0
+	mask := -isNotZero(a)
+	for i := 0; i < 4; i++ {
+		s[i] = x[i] & mask
+	}
+	return s
+}
+
+// Sub sets s to a - b and returns s.
+func (s *Scalar) Sub(a, b *Scalar) *Scalar {
+	var nb Scalar
+	nb.Neg(b)
+	s.Add(a, &nb)
+	nb.Zero()
+	return s
+}
+
+// Mul sets s to a * b and returns s.
+func (s *Scalar) Mul(a, b *Scalar) *Scalar {
+	var x [8]uint64
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			hi, lo := bits.Mul64(a[i], b[j])
+			addAt(x[:], i+j, lo)
+			addAt(x[:], i+j+1, hi)
+		}
+	}
+	*s = reduce(x)
+	return s
+}
+
+// Inverse sets s to a^-1 and returns s.
+// The inverse of 0 is 0.
+func (s *Scalar) Inverse(a *Scalar) *Scalar {
+	// a^(N-2) by Fermat's little theorem
+	// The exponent is public, so the branches do not leak a.
+	e := n
+	e[0] -= 2
+	x := Scalar{1}
+	b := *a
+	for i := 255; i >= 0; i-- {
+		x.Mul(&x, &x)
+		if e[i/64]>>uint(i%64)&1 == 1 {
+			x.Mul(&x, &b)
+		}
+	}
+	*s = x
+	x.Zero()
+	b.Zero()
+	return s
+}
+
+// IsZero returns true if s is 0.
+func (s *Scalar) IsZero() bool {
+	return isNotZero(s) == 0
+}
+
+// Zero clears s.
+func (s *Scalar) Zero() {
+	for i := range s {
+		s[i] = 0
+	}
+}
+
+// Zero clears the bytes b.
+func Zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// ZeroBig clears the words of x.
+func ZeroBig(x *big.Int) {
+	if x == nil {
+		return
+	}
+	ws := x.Bits()
+	for i := range ws {
+		ws[i] = 0
+	}
+	x.SetInt64(0)
+}
+
+// reduce returns x modulo N.
+func reduce(x [8]uint64) Scalar {
+	// x = x[0:4] + x[4:8] * (2^256 - N)
+	// 512 bits -> 386 bits -> 260 bits -> 257 bits -> 256 bits
+	for round := 0; round < 4; round++ {
+		var hi [4]uint64
+		copy(hi[:], x[4:])
+		for i := 4; i < 8; i++ {
+			x[i] = 0
+		}
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 3; j++ {
+				h, l := bits.Mul64(hi[i], c[j])
+				addAt(x[:], i+j, l)
+				addAt(x[:], i+j+1, h)
+			}
+		}
+	}
+	return subN([5]uint64{x[0], x[1], x[2], x[3], x[4]})
+}
+
+// subN returns x - N if x >= N, otherwise x. x must be less than 2N.
+func subN(x [5]uint64) Scalar {
+	var t Scalar
+	var borrow uint64
+	for i := 0; i < 4; i++ {
+		t[i], borrow = bits.Sub64(x[i], n[i], borrow)
+	}
+	_, borrow = bits.Sub64(x[4], 0, borrow)
+	// use t if no borrow
+	mask := borrow - 1
+	var s Scalar
+	for i := 0; i < 4; i++ {
+		s[i] = (t[i] & mask) | (x[i] &^ mask)
+	}
+	return s
+}
+
+// addAt adds v to x at word k with carry.
+func addAt(x []uint64, k int, v uint64) {
+	var carry uint64
+	x[k], carry = bits.Add64(x[k], v, 0)
+	for i := k + 1; i < len(x); i++ {
+		x[i], carry = bits.Add64(x[i], 0, carry)
+	}
+}
+
+// isNotZero returns 1 if s is not 0, otherwise 0.
+func isNotZero(s *Scalar) uint64 {
+	v := s[0] | s[1] | s[2] | s[3]
+	return (v | -v) >> 63
+}
+
+func bytesOf(s [4]uint64) [32]byte {
+	var b [32]byte
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 8; j++ {
+			b[31-i*8-j] = byte(s[i] >> uint(j*8))
+		}
+	}
+	return b
+}
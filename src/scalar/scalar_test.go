@@ -0,0 +1,90 @@
+package scalar
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// testValues returns edge and random values, some of them are not less than N.
+func testValues(t *testing.T) []*big.Int {
+	max := new(big.Int).Lsh(big.NewInt(1), 256)
+	one := big.NewInt(1)
+	vs := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(2),
+		new(big.Int).Sub(nBig, one),
+		new(big.Int).Set(nBig),
+		new(big.Int).Add(nBig, one),
+		new(big.Int).Sub(max, one),
+		new(big.Int).Lsh(one, 255),
+		new(big.Int).Lsh(one, 128),
+		new(big.Int).Sub(new(big.Int).Lsh(one, 64), one),
+	}
+	for i := 0; i < 64; i++ {
+		v, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vs = append(vs, v)
+	}
+	return vs
+}
+
+func toScalar(x *big.Int) *Scalar {
+	b := make([]byte, 32)
+	x.FillBytes(b)
+	return new(Scalar).SetBytes(b)
+}
+
+func check(t *testing.T, op string, got *Scalar, want *big.Int) {
+	t.Helper()
+	want = new(big.Int).Mod(want, nBig)
+	if got.Big().Cmp(want) != 0 {
+		t.Fatalf("%s : got %x, want %x", op, got.Big(), want)
+	}
+}
+
+func TestSetBytes(t *testing.T) {
+	for _, x := range testValues(t) {
+		check(t, "SetBytes", toScalar(x), x)
+		check(t, "SetBig", new(Scalar).SetBig(x), x)
+		check(t, "SetBig neg", new(Scalar).SetBig(new(big.Int).Neg(x)), new(big.Int).Neg(x))
+	}
+	// short bytes
+	check(t, "SetBytes short", new(Scalar).SetBytes([]byte{1, 2}), big.NewInt(0x0102))
+}
+
+func TestArithmetic(t *testing.T) {
+	vs := testValues(t)
+	for _, x := range vs {
+		a := toScalar(x)
+		check(t, "Neg", new(Scalar).Neg(a), new(big.Int).Neg(x))
+		inv := new(big.Int).ModInverse(new(big.Int).Mod(x, nBig), nBig)
+		if inv == nil {
+			inv = big.NewInt(0)
+		}
+		check(t, "Inverse", new(Scalar).Inverse(a), inv)
+		if a.IsZero() != (new(big.Int).Mod(x, nBig).Sign() == 0) {
+			t.Fatalf("IsZero : %x", x)
+		}
+		for _, y := range vs {
+			b := toScalar(y)
+			check(t, "Add", new(Scalar).Add(a, b), new(big.Int).Add(x, y))
+			check(t, "Sub", new(Scalar).Sub(a, b), new(big.Int).Sub(x, y))
+			check(t, "Mul", new(Scalar).Mul(a, b), new(big.Int).Mul(x, y))
+		}
+	}
+}
+
+func TestAliasing(t *testing.T) {
+	for _, x := range testValues(t) {
+		a := toScalar(x)
+		a.Mul(a, a)
+		check(t, "Mul alias", a, new(big.Int).Mul(x, x))
+		b := toScalar(x)
+		b.Add(b, b)
+		check(t, "Add alias", b, new(big.Int).Add(x, x))
+	}
+}
@@ -0,0 +1,155 @@
+// Package schnorr project adaptor.go
+package schnorr
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+
+	"ec"
+	"scalar"
+)
+
+// AdaptorSize is the size of adaptor signature,
+// which is the compressed nonce point and the pre-signature.
+const AdaptorSize = 33 + 32
+
+// EncSign returns the adaptor signature of the hash by the private key encrypted to the point.
+// The signature is completed with the discrete log of the point by Adapt.
+// aux is the 32 bytes auxiliary random data.
+func EncSign(pri *btcec.PrivateKey, hash []byte, point *btcec.PublicKey, aux []byte) ([]byte, error) {
+	if len(aux) != 32 {
+		return nil, fmt.Errorf("illegal aux size : %d", len(aux))
+	}
+	var d scalar.Scalar
+	db := pri.Serialize()
+	d.SetBytes(db)
+	scalar.Zero(db)
+	defer d.Zero()
+	if d.IsZero() {
+		return nil, fmt.Errorf("private key is zero")
+	}
+	pub := (*btcec.PublicKey)(&pri.PublicKey)
+	if !HasEvenY(pub) {
+		d.Neg(&d)
+	}
+	px := XOnly(pub)
+	// nonce, which depends on the point not to be reused for another point
+	t := d.Bytes()
+	defer scalar.Zero(t[:])
+	ec.XorBytes(t[:], TaggedHash("BIP0340/aux", aux))
+	var k scalar.Scalar
+	k.SetBytes(TaggedHash("DLC/adaptor/nonce", t[:], point.SerializeCompressed(), px, hash))
+	defer k.Zero()
+	if k.IsZero() {
+		return nil, fmt.Errorf("nonce is zero")
+	}
+	// R = k*G + T
+	kb := k.Bytes()
+	r := ec.Add(ec.BaseMul(kb[:]), point)
+	scalar.Zero(kb[:])
+	if r == nil {
+		return nil, fmt.Errorf("nonce point is infinity")
+	}
+	// The final nonce is -R if R has odd y, so that k and t are negated.
+	if !HasEvenY(r) {
+		k.Neg(&k)
+	}
+	// s' = k + e*d
+	var e, s scalar.Scalar
+	e.SetBytes(TaggedHash("BIP0340/challenge", XOnly(r), px, hash))
+	s.Mul(&e, &d)
+	s.Add(&s, &k)
+	sb := s.Bytes()
+	asig := append(r.SerializeCompressed(), sb[:]...)
+	err := EncVerify(pub, hash, point, asig)
+	if err != nil {
+		return nil, err
+	}
+	return asig, nil
+}
+
+// EncVerify verifies the adaptor signature of the hash by the public key encrypted to the point.
+func EncVerify(pub *btcec.PublicKey, hash []byte, point *btcec.PublicKey, asig []byte) error {
+	r, s, err := parseAdaptor(asig)
+	if err != nil {
+		return err
+	}
+	px := XOnly(pub)
+	p, err := ParseXOnly(px)
+	if err != nil {
+		return err
+	}
+	e := challenge(XOnly(r), px, hash)
+	// s'*G = R' - T' + e*P
+	// where R' and T' are negated if R has odd y
+	rt := ec.Add(r, ec.Neg(point))
+	if !HasEvenY(r) {
+		rt = ec.Neg(rt)
+	}
+	lhs := ec.BaseMul(s.Bytes())
+	rhs := ec.Add(rt, ec.Mul(p, e.Bytes()))
+	if !ec.IsEqual(lhs, rhs) {
+		return fmt.Errorf("verify fail : %x", asig)
+	}
+	return nil
+}
+
+// Adapt returns the signature from the adaptor signature
+// and the discrete log of the point encrypted to in 32 bytes big endian.
+func Adapt(asig []byte, secret []byte) ([]byte, error) {
+	r, s, err := parseAdaptor(asig)
+	if err != nil {
+		return nil, err
+	}
+	if len(secret) != 32 {
+		return nil, fmt.Errorf("illegal secret size : %d", len(secret))
+	}
+	var st, t scalar.Scalar
+	st.SetBig(s)
+	t.SetBytes(secret)
+	defer t.Zero()
+	if !HasEvenY(r) {
+		t.Neg(&t)
+	}
+	st.Add(&st, &t)
+	sb := st.Bytes()
+	return append(XOnly(r), sb[:]...), nil
+}
+
+// Extract returns the discrete log of the point encrypted to
+// from the adaptor signature and the signature adapted.
+func Extract(asig, sig []byte) (*big.Int, error) {
+	r, s, err := parseAdaptor(asig)
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) != SigSize || new(big.Int).SetBytes(sig[:32]).Cmp(r.X) != 0 {
+		return nil, fmt.Errorf("signature does not match the adaptor : %x", sig)
+	}
+	var a, b scalar.Scalar
+	a.SetBytes(sig[32:])
+	b.SetBig(s)
+	a.Sub(&a, &b)
+	if !HasEvenY(r) {
+		a.Neg(&a)
+	}
+	return a.Big(), nil
+}
+
+// parseAdaptor returns the nonce point and the pre-signature.
+func parseAdaptor(asig []byte) (*btcec.PublicKey, *big.Int, error) {
+	if len(asig) != AdaptorSize {
+		return nil, nil, fmt.Errorf("illegal adaptor signature size : %d", len(asig))
+	}
+	r, err := ec.Parse(asig[:33])
+	if err != nil {
+		return nil, nil, err
+	}
+	s := new(big.Int).SetBytes(asig[33:])
+	if s.Cmp(curve.N) >= 0 {
+		return nil, nil, fmt.Errorf("illegal adaptor signature : %x", asig)
+	}
+	return r, s, nil
+}
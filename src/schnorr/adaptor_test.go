@@ -0,0 +1,104 @@
+package schnorr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+func TestAdaptor(t *testing.T) {
+	odd := map[bool]int{}
+	for i := byte(0); i < 16; i++ {
+		k := sha256.Sum256([]byte{'k', i})
+		pri, pub := btcec.PrivKeyFromBytes(curve, k[:])
+		y := sha256.Sum256([]byte{'s', i})
+		sec, point := btcec.PrivKeyFromBytes(curve, y[:])
+		hash := sha256.Sum256([]byte{i})
+		aux := bytes.Repeat([]byte{i}, 32)
+		asig, err := EncSign(pri, hash[:], point, aux)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = EncVerify(pub, hash[:], point, asig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _, _ := parseAdaptor(asig)
+		odd[!HasEvenY(r)]++
+		sig, err := Adapt(asig, y[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Verify(pub, hash[:], sig)
+		if err != nil {
+			t.Fatalf("adapted signature : %v", err)
+		}
+		secret, err := Extract(asig, sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if secret.Cmp(sec.D) != 0 {
+			t.Fatalf("extracted secret mismatch : %x, %x", secret, sec.D)
+		}
+	}
+	if odd[true] == 0 || odd[false] == 0 {
+		t.Fatalf("nonce points of odd y are not covered : %v", odd)
+	}
+}
+
+func TestAdaptorErrors(t *testing.T) {
+	k := sha256.Sum256([]byte("key"))
+	pri, pub := btcec.PrivKeyFromBytes(curve, k[:])
+	y := sha256.Sum256([]byte("secret"))
+	_, point := btcec.PrivKeyFromBytes(curve, y[:])
+	hash := sha256.Sum256([]byte("hash"))
+	aux := make([]byte, 32)
+	asig, err := EncSign(pri, hash[:], point, aux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := append([]byte{}, asig...)
+	tampered[AdaptorSize-1] ^= 0x01
+	if EncVerify(pub, hash[:], point, tampered) == nil {
+		t.Fatalf("tampered adaptor is verified")
+	}
+	if EncVerify(pub, hash[:], pub, asig) == nil {
+		t.Fatalf("adaptor is verified by another point")
+	}
+	if EncVerify(point, hash[:], point, asig) == nil {
+		t.Fatalf("adaptor is verified by another key")
+	}
+	// The adaptor is not a signature before adapted.
+	if Verify(pub, hash[:], append(XOnly(nonceOf(t, asig)), asig[33:]...)) == nil {
+		t.Fatalf("adaptor is verified as signature")
+	}
+	sig, err := Adapt(asig, y[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Adapt(asig, y[1:]); err == nil {
+		t.Fatalf("short secret is adapted")
+	}
+	other, err := Sign(pri, hash[:], aux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Extract(asig, other); err == nil {
+		t.Fatalf("secret is extracted from another signature")
+	}
+	if _, err = Extract(asig, sig[:SigSize-1]); err == nil {
+		t.Fatalf("secret is extracted from short signature")
+	}
+}
+
+// nonceOf returns the nonce point of the adaptor signature.
+func nonceOf(t *testing.T, asig []byte) *btcec.PublicKey {
+	t.Helper()
+	r, _, err := parseAdaptor(asig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
@@ -0,0 +1,152 @@
+// Package schnorr project schnorr.go
+package schnorr
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+
+	"ec"
+	"scalar"
+)
+
+// Sizes of BIP340 data (byte)
+const (
+	// KeySize is the size of x-only public key.
+	KeySize = 32
+	// SigSize is the size of signature.
+	SigSize = 64
+)
+
+// curve is secp256k1.
+var curve = btcec.S256()
+
+// TaggedHash returns the BIP340 tagged hash of the messages.
+func TaggedHash(tag string, msgs ...[]byte) []byte {
+	th := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(th[:])
+	h.Write(th[:])
+	for _, m := range msgs {
+		h.Write(m)
+	}
+	return h.Sum(nil)
+}
+
+// XOnly returns the 32 bytes x coordinate of the public key.
+func XOnly(pub *btcec.PublicKey) []byte {
+	return ec.Pad32(pub.X.Bytes())
+}
+
+// HasEvenY returns true if y coordinate of the public key is even.
+func HasEvenY(pub *btcec.PublicKey) bool {
+	return pub.Y.Bit(0) == 0
+}
+
+// ParseXOnly returns the public key of even y with the x-only public key.
+func ParseXOnly(b []byte) (*btcec.PublicKey, error) {
+	if len(b) != KeySize {
+		return nil, fmt.Errorf("illegal x-only public key size : %d", len(b))
+	}
+	x := new(big.Int).SetBytes(b)
+	p := curve.P
+	if x.Cmp(p) >= 0 {
+		return nil, fmt.Errorf("x-only public key is over the field : %x", b)
+	}
+	// y = (x^3 + 7)^((p+1)/4)
+	c := new(big.Int).Exp(x, big.NewInt(3), p)
+	c.Add(c, curve.B)
+	c.Mod(c, p)
+	e := new(big.Int).Add(p, big.NewInt(1))
+	e.Rsh(e, 2)
+	y := new(big.Int).Exp(c, e, p)
+	if new(big.Int).Exp(y, big.NewInt(2), p).Cmp(c) != 0 {
+		return nil, fmt.Errorf("x-only public key is not on the curve : %x", b)
+	}
+	if y.Bit(0) != 0 {
+		y.Sub(p, y)
+	}
+	return ec.New(x, y), nil
+}
+
+// Sign returns the BIP340 signature of the hash by the private key.
+// aux is the 32 bytes auxiliary random data.
+func Sign(pri *btcec.PrivateKey, hash, aux []byte) ([]byte, error) {
+	if len(aux) != 32 {
+		return nil, fmt.Errorf("illegal aux size : %d", len(aux))
+	}
+	var d scalar.Scalar
+	db := pri.Serialize()
+	d.SetBytes(db)
+	scalar.Zero(db)
+	defer d.Zero()
+	if d.IsZero() {
+		return nil, fmt.Errorf("private key is zero")
+	}
+	pub := (*btcec.PublicKey)(&pri.PublicKey)
+	if !HasEvenY(pub) {
+		d.Neg(&d)
+	}
+	px := XOnly(pub)
+	// nonce
+	t := d.Bytes()
+	defer scalar.Zero(t[:])
+	ec.XorBytes(t[:], TaggedHash("BIP0340/aux", aux))
+	var k scalar.Scalar
+	k.SetBytes(TaggedHash("BIP0340/nonce", t[:], px, hash))
+	defer k.Zero()
+	if k.IsZero() {
+		return nil, fmt.Errorf("nonce is zero")
+	}
+	kb := k.Bytes()
+	r := ec.BaseMul(kb[:])
+	scalar.Zero(kb[:])
+	if !HasEvenY(r) {
+		k.Neg(&k)
+	}
+	rx := XOnly(r)
+	// s = k + e*d
+	var e, s scalar.Scalar
+	e.SetBytes(TaggedHash("BIP0340/challenge", rx, px, hash))
+	s.Mul(&e, &d)
+	s.Add(&s, &k)
+	sb := s.Bytes()
+	sig := append(rx, sb[:]...)
+	err := Verify(pub, hash, sig)
+	if err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// Verify verifies the BIP340 signature of the hash by the x coordinate of the public key.
+func Verify(pub *btcec.PublicKey, hash, sig []byte) error {
+	if len(sig) != SigSize {
+		return fmt.Errorf("illegal signature size : %d", len(sig))
+	}
+	px := XOnly(pub)
+	p, err := ParseXOnly(px)
+	if err != nil {
+		return err
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(curve.P) >= 0 || s.Cmp(curve.N) >= 0 {
+		return fmt.Errorf("illegal signature : %x", sig)
+	}
+	e := challenge(sig[:32], px, hash)
+	// R = s*G - e*P
+	rp := ec.Add(ec.BaseMul(sig[32:]), ec.Neg(ec.Mul(p, e.Bytes())))
+	if rp == nil || !HasEvenY(rp) || rp.X.Cmp(r) != 0 {
+		return fmt.Errorf("verify fail : %x", sig)
+	}
+	return nil
+}
+
+// challenge returns the BIP340 challenge modulo N.
+func challenge(rx, px, hash []byte) *big.Int {
+	e := new(big.Int).SetBytes(TaggedHash("BIP0340/challenge", rx, px, hash))
+	return e.Mod(e, curve.N)
+}
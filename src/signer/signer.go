@@ -0,0 +1,66 @@
+// signer project signer.go
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+
+	"oracle"
+)
+
+func main() {
+	log.SetOutput(os.Stderr)
+	log.SetFlags(log.LstdFlags + log.Lshortfile)
+	name := flag.String("name", "Olivia", "oracle name")
+	socket := flag.String("socket", "", "unix socket path (stdin/stdout if empty)")
+	state := flag.String("state", "", "file path of attested events")
+	flag.Parse()
+
+	// TODO
+	seed := chainhash.DoubleHashB([]byte(*name))
+	signer, err := oracle.NewLocalSigner(seed, chaincfg.RegressionNetParams)
+	if err != nil {
+		log.Fatalf("NewLocalSigner error : %v", err)
+	}
+	if *state != "" {
+		err = signer.SetStateFile(*state)
+		if err != nil {
+			log.Fatalf("SetStateFile error : %v", err)
+		}
+	}
+	if *socket == "" {
+		err = oracle.ServeSigner(signer, os.Stdin, os.Stdout)
+		if err != nil {
+			log.Fatalf("ServeSigner error : %v", err)
+		}
+		return
+	}
+	os.Remove(*socket)
+	ln, err := net.Listen("unix", *socket)
+	if err != nil {
+		log.Fatalf("net.Listen error : %v", err)
+	}
+	err = os.Chmod(*socket, 0600)
+	if err != nil {
+		log.Fatalf("os.Chmod error : %v", err)
+	}
+	log.Printf("signer listen %s", *socket)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Fatalf("Accept error : %v", err)
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			err := oracle.ServeSigner(signer, conn, conn)
+			if err != nil {
+				log.Printf("ServeSigner error : %v", err)
+			}
+		}(conn)
+	}
+}
@@ -0,0 +1,39 @@
+// Package usr project adaptor.go
+package usr
+
+import (
+	"github.com/btcsuite/btcd/wire"
+
+	"dlc"
+)
+
+// signSettlementTx returns own adaptor signature of settlement transaction of rate
+// encrypted to the rate key, which the other decrypts with the oracle signs.
+func (u *User) signSettlementTx(tx *wire.MsgTx, rate *dlc.Rate) ([]byte, error) {
+	hash, err := u.dlc.FundSigHash(tx, false)
+	if err != nil {
+		return nil, err
+	}
+	pub := u.dlc.PublicKey(u.dlc.IsA())
+	if u.dlc.IsTaproot() {
+		return u.wallet.GetAdaptorSignature(hash, pub, rate.Key())
+	}
+	return u.wallet.GetECDSAAdaptorSignature(hash, pub, rate.Key())
+}
+
+// settlementWitness returns the witness of settlement transaction of the fixed rate.
+func (u *User) settlementWitness(tx *wire.MsgTx, rate *dlc.Rate) (wire.TxWitness, error) {
+	own, err := u.signFundTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	// the signature of the other is decrypted by the oracle signs
+	other, err := u.dlc.AdaptedSign(rate)
+	if err != nil {
+		return nil, err
+	}
+	if u.dlc.IsA() {
+		return u.dlc.FundWitness(own, other)
+	}
+	return u.dlc.FundWitness(other, own)
+}
@@ -0,0 +1,221 @@
+// Package usr project batch.go
+package usr
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+
+	"dlc"
+)
+
+// Batch is the coordinator of contracts whose fund outputs are in a single fund transaction.
+// The users of a batch have the same wallet, and a utxo is not used by two contracts.
+// The counterparties of the contracts may be the same or different.
+type Batch struct {
+	users []*User // users of contracts
+}
+
+// NewBatch returns a new Batch of users.
+func NewBatch(users []*User) (*Batch, error) {
+	if len(users) < 2 {
+		return nil, fmt.Errorf("batch needs users : %d", len(users))
+	}
+	for _, u := range users {
+		if u.batch != nil {
+			return nil, fmt.Errorf("user is already in batch : %s", u.name)
+		}
+	}
+	b := &Batch{users}
+	for _, u := range users {
+		u.batch = b
+	}
+	return b, nil
+}
+
+// BatchData is the batch dataset of the fund transaction fixed by the offerer.
+type BatchData struct {
+	ID         string   `json:"id"`         // temporary contract id
+	ContractID string   `json:"contractid"` // contract id
+	Inputs     []string `json:"inputs"`     // inputs of the other contracts
+	Iserials   []uint64 `json:"iserials"`   // serial ids of inputs
+	Outputs    []string `json:"outputs"`    // outputs of the other contracts
+	Oserials   []uint64 `json:"oserials"`   // serial ids of outputs
+	Signs      []string `json:"signs"`      // signatures of the settlement transaction
+	Rsign      string   `json:"rsign"`      // signature of the refund transaction
+}
+
+// GetBatchData places the fund outputs of the contracts offered in a single fund transaction,
+// and returns Serialized BatchData of each contract in order of users.
+// The acceptors send SignData after they set BatchData.
+func (b *Batch) GetBatchData() ([][]byte, error) {
+	dlcs := []*dlc.Dlc{}
+	for _, u := range b.users {
+		if u.status != StatusCanGetBatch {
+			return nil, fmt.Errorf("illegal status : %s, %d", u.name, u.status)
+		}
+		dlcs = append(dlcs, u.dlc)
+	}
+	err := dlc.SetBatch(dlcs)
+	if err != nil {
+		return nil, err
+	}
+	list := [][]byte{}
+	for _, u := range b.users {
+		bs, err := u.getBatchData()
+		if err != nil {
+			for _, d := range dlcs {
+				d.SetBatchTxInsAndTxOuts(nil, nil)
+			}
+			return nil, err
+		}
+		list = append(list, bs)
+	}
+	for _, u := range b.users {
+		err = u.setStatus(StatusWaitForSign)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// getBatchData returns Serialized BatchData with own signatures of the batch.
+func (u *User) getBatchData() ([]byte, error) {
+	err := u.dlc.Validate()
+	if err != nil {
+		return nil, err
+	}
+	err = u.checkStandard()
+	if err != nil {
+		return nil, err
+	}
+	signs, rsign, err := u.signContractTxs()
+	if err != nil {
+		return nil, err
+	}
+	bdata := &BatchData{}
+	bdata.ID = u.TemporaryID()
+	bdata.ContractID = u.ContractID()
+	bdata.Inputs, bdata.Iserials = FundTxInsToStrs(u.dlc.BatchTxIns())
+	bdata.Outputs, bdata.Oserials = FundTxOutsToStrs(u.dlc.BatchTxOuts())
+	bdata.Signs = signs
+	bdata.Rsign = hex.EncodeToString(rsign)
+	bs, _ := json.Marshal(bdata)
+	return bs, nil
+}
+
+// SetBatchData sets Serialized BatchData.
+func (u *User) SetBatchData(data []byte) error {
+	if u.status != StatusWaitForBatch {
+		return fmt.Errorf("illegal status : %d", u.status)
+	}
+	var bdata BatchData
+	err := json.Unmarshal(data, &bdata)
+	if err != nil {
+		return err
+	}
+	txins, err := StrsToFundTxIns(bdata.Inputs, bdata.Iserials)
+	if err != nil {
+		return err
+	}
+	txouts, err := StrsToFundTxOuts(bdata.Outputs, bdata.Oserials)
+	if err != nil {
+		return err
+	}
+	err = u.dlc.SetBatchTxInsAndTxOuts(txins, txouts)
+	if err != nil {
+		return err
+	}
+	err = u.setBatchData(&bdata)
+	if err != nil {
+		u.dlc.SetBatchTxInsAndTxOuts(nil, nil)
+		return err
+	}
+	return u.setStatus(StatusCanGetSign)
+}
+
+// setBatchData checks the fund transaction of batch and verifies the signatures.
+func (u *User) setBatchData(bdata *BatchData) error {
+	err := u.checkIDs(bdata.ID, bdata.ContractID)
+	if err != nil {
+		return err
+	}
+	err = u.dlc.Validate()
+	if err != nil {
+		return err
+	}
+	err = u.checkStandard()
+	if err != nil {
+		return err
+	}
+	return u.verifyContractTxs(bdata.Signs, bdata.Rsign)
+}
+
+// SendFundTx sends the fund transaction of batch
+// after the witnesses of all contracts are received.
+func (b *Batch) SendFundTx() error {
+	var tx *wire.MsgTx
+	for _, u := range b.users {
+		if u.status != StatusWaitSendTx || !u.batched {
+			return fmt.Errorf("illegal status : %s, %d", u.name, u.status)
+		}
+		ftx := u.dlc.FundTx()
+		if tx == nil {
+			tx = ftx
+		} else if ftx.TxHash() != tx.TxHash() {
+			return fmt.Errorf("fund transaction mismatch : %v, %v", ftx.TxHash(), tx.TxHash())
+		}
+	}
+	// the witnesses of the others
+	tws := map[wire.OutPoint]wire.TxWitness{}
+	for _, u := range b.users {
+		for _, txin := range u.dlc.FundTxIns(!u.dlc.IsA()) {
+			tws[txin.PreviousOutPoint] = txin.Witness
+		}
+	}
+	for _, txin := range tx.TxIn {
+		txin.Witness = tws[txin.PreviousOutPoint]
+	}
+	// own witnesses
+	u := b.users[0]
+	err := u.wallet.SignTx(tx)
+	if err != nil {
+		return err
+	}
+	for idx, txin := range tx.TxIn {
+		if txin.Witness == nil {
+			return fmt.Errorf("witness is missing : txin[%d] %v", idx, txin.PreviousOutPoint)
+		}
+	}
+	txid, err := u.wallet.SendTx(tx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s sends the batch Fund Transaction :%v\n", u.name, txid)
+	for _, u := range b.users {
+		vout := u.dlc.FundVout()
+		fmt.Printf("txout[%d]: %10d / %x contract:%s\n", vout, tx.TxOut[vout].Value,
+			tx.TxOut[vout].PkScript, u.contractLabel())
+	}
+	return nil
+}
+
+// batchExcludes returns the outpoints used by the other contracts of own batch.
+func (u *User) batchExcludes() []wire.OutPoint {
+	if u.batch == nil {
+		return nil
+	}
+	ops := []wire.OutPoint{}
+	for _, other := range u.batch.users {
+		if other == u || other.dlc == nil {
+			continue
+		}
+		for _, txin := range other.dlc.FundTxIns(other.dlc.IsA()) {
+			ops = append(ops, txin.PreviousOutPoint)
+		}
+	}
+	return ops
+}
@@ -0,0 +1,75 @@
+// Package usr project bond.go
+package usr
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+
+	"oracle"
+)
+
+// SetMinBondAmount sets the minimum amount of oracle bond.
+// If amount is greater than zero, oracle without bond is not accepted.
+func (u *User) SetMinBondAmount(amount int64) {
+	u.bondAmt = amount
+}
+
+// verifyBond verifies the oracle bond by own node.
+func (u *User) verifyBond(pub *btcec.PublicKey, bond *oracle.Bond) error {
+	if bond == nil {
+		if u.bondAmt > 0 {
+			return fmt.Errorf("oracle bond is required")
+		}
+		return nil
+	}
+	pkScript, err := oracle.VerifyBond(pub, bond)
+	if err != nil {
+		return err
+	}
+	if bond.Amount < u.bondAmt {
+		return fmt.Errorf("oracle bond is short : %d, %d", bond.Amount, u.bondAmt)
+	}
+	// The bond must be locked until the contract is over.
+	locktime := u.dlc.RefundLocktime()
+	if bond.Locktime < txscript.LockTimeThreshold {
+		if locktime >= txscript.LockTimeThreshold || bond.Locktime < locktime {
+			return fmt.Errorf("oracle bond locktime is early : %d, %d", bond.Locktime, locktime)
+		}
+	} else {
+		info, err := u.getChainInfo()
+		if err != nil {
+			return err
+		}
+		if int64(bond.Locktime) <= info.Mediantime {
+			return fmt.Errorf("oracle bond locktime is expired : %d, %d", bond.Locktime, info.Mediantime)
+		}
+	}
+	// utxo of bond
+	res, err := u.rpc.Request("gettxout", bond.Txid, bond.Vout, true)
+	if err != nil {
+		return err
+	}
+	if res.Result == nil {
+		return fmt.Errorf("oracle bond is not found : %s:%d", bond.Txid, bond.Vout)
+	}
+	txout := &oracle.TxOutResult{}
+	err = res.UnmarshalResult(txout)
+	if err != nil {
+		return err
+	}
+	if txout.ScriptPubKey.Hex != hex.EncodeToString(pkScript) {
+		return fmt.Errorf("illegal oracle bond pkScript : %s", txout.ScriptPubKey.Hex)
+	}
+	amt, err := btcutil.NewAmount(txout.Value)
+	if err != nil {
+		return err
+	}
+	if int64(amt) != bond.Amount {
+		return fmt.Errorf("illegal oracle bond amount : %d, %d", int64(amt), bond.Amount)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+// Package usr project close.go
+package usr
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+
+	"musig2"
+)
+
+// CloseOfferData is the close offer dataset.
+type CloseOfferData struct {
+	ID         string `json:"id"`         // temporary contract id
+	ContractID string `json:"contractid"` // contract id
+	Oamount    int64  `json:"oamount"`    // payout of offerer (satoshi)
+	Aamount    int64  `json:"aamount"`    // payout of acceptor (satoshi)
+	Sign       string `json:"sign"`       // signature of the close transaction
+	Nonce      string `json:"nonce"`      // MuSig2 public nonce in taproot mode
+}
+
+// CloseAcceptData is the close accept dataset.
+type CloseAcceptData struct {
+	ID         string `json:"id"`         // temporary contract id
+	ContractID string `json:"contractid"` // contract id
+	Sign       string `json:"sign"`       // signature or MuSig2 partial signature of the close transaction
+	Nonce      string `json:"nonce"`      // MuSig2 public nonce in taproot mode
+}
+
+// GetCloseOfferData returns Serialized CloseOfferData.
+// amount is own payout and the rest of fund output after the fee at efee is paid to the other.
+// In taproot mode, it has the MuSig2 public nonce instead of the signature.
+func (u *User) GetCloseOfferData(amount, efee int64) ([]byte, error) {
+	if u.status != StatusWaitSendTx {
+		return nil, fmt.Errorf("illegal status : %d", u.status)
+	}
+	isA := u.dlc.IsA()
+	amta, amtb, err := u.dlc.CloseAmounts(amount, isA, efee)
+	if err != nil {
+		return nil, err
+	}
+	err = u.dlc.CheckCloseTx(amta, amtb)
+	if err != nil {
+		return nil, err
+	}
+	cdata := &CloseOfferData{}
+	if u.dlc.IsTaproot() {
+		u.closeNonce, u.closePnonce, err = u.dlc.CloseNonce(amta, amtb)
+		if err != nil {
+			return nil, err
+		}
+		cdata.Nonce = hex.EncodeToString(u.closePnonce)
+	} else {
+		sign, err := u.signCloseTx(amta, amtb)
+		if err != nil {
+			return nil, err
+		}
+		cdata.Sign = hex.EncodeToString(sign)
+	}
+	cdata.ID = u.TemporaryID()
+	cdata.ContractID = u.ContractID()
+	cdata.Oamount = amount
+	cdata.Aamount = amtb
+	if !isA {
+		cdata.Aamount = amta
+	}
+	bs, _ := json.Marshal(cdata)
+	u.closea, u.closeb = amta, amtb
+	err = u.setStatus(StatusWaitForCloseAccept)
+	if err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+// SetCloseOfferData sets Serialized CloseOfferData.
+func (u *User) SetCloseOfferData(data []byte) error {
+	if u.status != StatusWaitSendTx {
+		return fmt.Errorf("illegal status : %d", u.status)
+	}
+	var cdata CloseOfferData
+	err := json.Unmarshal(data, &cdata)
+	if err != nil {
+		return err
+	}
+	err = u.checkIDs(cdata.ID, cdata.ContractID)
+	if err != nil {
+		return err
+	}
+	amta, amtb := cdata.Aamount, cdata.Oamount
+	if !u.dlc.IsA() {
+		amta, amtb = amtb, amta
+	}
+	sign, err := hex.DecodeString(cdata.Sign)
+	if err != nil {
+		return err
+	}
+	nonce, err := hex.DecodeString(cdata.Nonce)
+	if err != nil {
+		return err
+	}
+	if u.dlc.IsTaproot() {
+		err = u.dlc.CheckCloseAmounts(amta, amtb)
+		if err == nil {
+			_, err = musig2.NonceAgg([][]byte{nonce})
+		}
+	} else {
+		err = u.dlc.VerifyCloseTx(amta, amtb, sign, u.dlc.PublicKey(!u.dlc.IsA()))
+	}
+	if err != nil {
+		return err
+	}
+	err = u.dlc.CheckCloseTx(amta, amtb)
+	if err != nil {
+		return err
+	}
+	u.closea, u.closeb = amta, amtb
+	u.closeSign = sign
+	u.closeOnonce = nonce
+	return u.setStatus(StatusCanGetCloseAccept)
+}
+
+// GetCloseAcceptData returns Serialized CloseAcceptData.
+// In taproot mode, it has the MuSig2 public nonce and partial signature,
+// and the offerer sends the close transaction.
+func (u *User) GetCloseAcceptData() ([]byte, error) {
+	if u.status != StatusCanGetCloseAccept {
+		return nil, fmt.Errorf("illegal status : %d", u.status)
+	}
+	cdata := &CloseAcceptData{}
+	cdata.ID = u.TemporaryID()
+	cdata.ContractID = u.ContractID()
+	if u.dlc.IsTaproot() {
+		sec, nonce, err := u.dlc.CloseNonce(u.closea, u.closeb)
+		if err != nil {
+			return nil, err
+		}
+		session, err := u.dlc.CloseSession(u.closea, u.closeb, [][]byte{u.closeOnonce, nonce})
+		if err != nil {
+			return nil, err
+		}
+		psig, err := u.wallet.GetPartialSignature(session, sec, u.dlc.PublicKey(u.dlc.IsA()))
+		if err != nil {
+			return nil, err
+		}
+		cdata.Sign = hex.EncodeToString(psig)
+		cdata.Nonce = hex.EncodeToString(nonce)
+		bs, _ := json.Marshal(cdata)
+		err = u.setStatus(StatusWaitForCloseTx)
+		if err != nil {
+			return nil, err
+		}
+		return bs, nil
+	}
+	sign, err := u.signCloseTx(u.closea, u.closeb)
+	if err != nil {
+		return nil, err
+	}
+	cdata.Sign = hex.EncodeToString(sign)
+	bs, _ := json.Marshal(cdata)
+	err = u.setStatus(StatusCanSendCloseTx)
+	if err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+// SetCloseAcceptData sets Serialized CloseAcceptData.
+func (u *User) SetCloseAcceptData(data []byte) error {
+	if u.status != StatusWaitForCloseAccept {
+		return fmt.Errorf("illegal status : %d", u.status)
+	}
+	var cdata CloseAcceptData
+	err := json.Unmarshal(data, &cdata)
+	if err != nil {
+		return err
+	}
+	err = u.checkIDs(cdata.ID, cdata.ContractID)
+	if err != nil {
+		return err
+	}
+	sign, err := hex.DecodeString(cdata.Sign)
+	if err != nil {
+		return err
+	}
+	if u.dlc.IsTaproot() {
+		sign, err = u.aggregateCloseSign(sign, cdata.Nonce)
+	} else {
+		err = u.dlc.VerifyCloseTx(u.closea, u.closeb, sign, u.dlc.PublicKey(!u.dlc.IsA()))
+	}
+	if err != nil {
+		return err
+	}
+	u.closeSign = sign
+	return u.setStatus(StatusCanSendCloseTx)
+}
+
+// CancelClose cancels the close offered or received.
+func (u *User) CancelClose() error {
+	switch u.status {
+	case StatusWaitForCloseAccept, StatusCanGetCloseAccept, StatusCanSendCloseTx,
+		StatusWaitForCloseTx:
+	default:
+		return fmt.Errorf("illegal status : %d", u.status)
+	}
+	u.closea, u.closeb = 0, 0
+	u.closeSign = nil
+	if u.closeNonce != nil {
+		u.closeNonce.Zero()
+	}
+	u.closeNonce, u.closePnonce, u.closeOnonce = nil, nil, nil
+	return u.setStatus(StatusWaitSendTx)
+}
+
+// SendCloseTx sends the close transaction.
+func (u *User) SendCloseTx() error {
+	if u.status != StatusCanSendCloseTx {
+		return fmt.Errorf("illegal status : %d", u.status)
+	}
+	tx := u.dlc.CloseTx(u.closea, u.closeb)
+	if u.dlc.IsTaproot() {
+		// MuSig2 signature for the key path
+		tx.TxIn[0].Witness = wire.TxWitness{u.closeSign}
+	} else {
+		sign, err := u.signCloseTx(u.closea, u.closeb)
+		if err != nil {
+			return err
+		}
+		signa, signb := sign, u.closeSign
+		if !u.dlc.IsA() {
+			signa, signb = signb, signa
+		}
+		tx.TxIn[0].Witness = u.dlc.CloseWitness(signa, signb)
+	}
+	txid, err := u.wallet.SendTx(tx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s sends the Close Transaction : %v contract:%s\n", u.name, txid, u.contractLabel())
+	for idx, txin := range tx.TxIn {
+		fmt.Printf("txin [%d]: %v\n", idx, txin.PreviousOutPoint)
+	}
+	for idx, txout := range tx.TxOut {
+		fmt.Printf("txout[%d]: %10d / %x\n", idx, txout.Value, txout.PkScript)
+	}
+	return nil
+}
+
+// signCloseTx returns own signature of close transaction.
+func (u *User) signCloseTx(amta, amtb int64) ([]byte, error) {
+	return u.signFundTx(u.dlc.CloseTx(amta, amtb))
+}
+
+// aggregateCloseSign returns the MuSig2 signature of close transaction
+// from the partial signature and public nonce of the other.
+func (u *User) aggregateCloseSign(psig []byte, nonce string) ([]byte, error) {
+	onon, err := hex.DecodeString(nonce)
+	if err != nil {
+		return nil, err
+	}
+	session, err := u.dlc.CloseSession(u.closea, u.closeb, [][]byte{u.closePnonce, onon})
+	if err != nil {
+		return nil, err
+	}
+	err = session.Verify(psig, onon, u.dlc.PublicKey(!u.dlc.IsA()))
+	if err != nil {
+		return nil, err
+	}
+	pub := u.dlc.PublicKey(u.dlc.IsA())
+	own, err := u.wallet.GetPartialSignature(session, u.closeNonce, pub)
+	if err != nil {
+		return nil, err
+	}
+	return session.Aggregate([][]byte{own, psig})
+}
@@ -0,0 +1,127 @@
+// Package usr project evidence.go
+package usr
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+
+	"dlc"
+	"oracle"
+)
+
+// DefaultMinConfirmations is the default confirmation depth of the target block.
+const DefaultMinConfirmations = 1
+
+// Evidence is the record of the oracle signed a wrong block hash.
+type Evidence struct {
+	Height   int      `json:"height"`   // height of target block
+	Attested string   `json:"attested"` // block hash signed by oracle
+	Expected string   `json:"expected"` // block hash of own node
+	Pubkey   string   `json:"pubkey"`   // oracle public key
+	Keys     []string `json:"keys"`     // oracle contract keys
+	Msgs     []string `json:"msgs"`     // messages signed by oracle
+	Signs    []string `json:"signs"`    // signatures of oracle
+}
+
+// SetMinConfirmations sets the confirmation depth required for the target block.
+func (u *User) SetMinConfirmations(confs int) {
+	u.confs = confs
+}
+
+// Evidences returns the records of the wrong oracle signatures.
+func (u *User) Evidences() []*Evidence {
+	return u.evidences
+}
+
+// checkOracleHash compares the block hash signed by oracle with own node.
+func (u *User) checkOracleHash(hash *chainhash.Hash, osigs *oracle.Signs) error {
+	height := u.dlc.GameHeight()
+	res, err := u.rpc.Request("getblockcount")
+	if err != nil {
+		return err
+	}
+	count, _ := res.Result.(float64)
+	confs := int(count) - height + 1
+	if confs < u.confs {
+		return fmt.Errorf("not enough confirmations : %d, %d", confs, u.confs)
+	}
+	res, err = u.rpc.Request("getblockhash", height)
+	if err != nil {
+		return err
+	}
+	result, _ := res.Result.(string)
+	expected, err := chainhash.NewHashFromStr(result)
+	if err != nil {
+		return err
+	}
+	if hash.IsEqual(expected) {
+		return nil
+	}
+	// The signatures are kept as evidence only if they are oracle's
+	// and their messages are the digits of the attested hash.
+	err = checkHashMessages(hash, osigs)
+	if err != nil {
+		return err
+	}
+	err = u.verifyOracleSigns(osigs)
+	if err != nil {
+		return err
+	}
+	e := &Evidence{}
+	e.Height = height
+	e.Attested = hash.String()
+	e.Expected = expected.String()
+	e.Pubkey = hex.EncodeToString(u.dlc.OraclePublicKey().SerializeCompressed())
+	for _, key := range u.dlc.OracleKeys() {
+		e.Keys = append(e.Keys, hex.EncodeToString(key.SerializeCompressed()))
+	}
+	e.Msgs = osigs.Msgs
+	e.Signs = osigs.Signs
+	u.evidences = append(u.evidences, e)
+	bs, _ := json.Marshal(e)
+	log.Printf("%s oracle evidence : contract:%s %s", u.name, u.contractLabel(), bs)
+	return fmt.Errorf("oracle signed wrong block hash : %v, %v", hash, expected)
+}
+
+// checkHashMessages checks that the messages signed by oracle are the digits of hash.
+func checkHashMessages(hash *chainhash.Hash, osigs *oracle.Signs) error {
+	msgs := dlc.HashMessages(hash)
+	if len(msgs) != len(osigs.Msgs) {
+		return fmt.Errorf("illegal oracle msgs size : %d, %d", len(msgs), len(osigs.Msgs))
+	}
+	for i, m := range msgs {
+		if osigs.Msgs[i] != hex.EncodeToString(m) {
+			return fmt.Errorf("oracle msg is not digit of hash : %d, %s, %v", i, osigs.Msgs[i], hash)
+		}
+	}
+	return nil
+}
+
+// verifyOracleSigns verifies each signature of oracle.
+func (u *User) verifyOracleSigns(osigs *oracle.Signs) error {
+	pub := u.dlc.OraclePublicKey()
+	keys := u.dlc.OracleKeys()
+	if pub == nil || len(keys) != len(osigs.Msgs) || len(keys) != len(osigs.Signs) {
+		return fmt.Errorf("illegal oracle signs size : %d, %d, %d",
+			len(keys), len(osigs.Msgs), len(osigs.Signs))
+	}
+	for i, key := range keys {
+		m, err := hex.DecodeString(osigs.Msgs[i])
+		if err != nil {
+			return err
+		}
+		bs, err := hex.DecodeString(osigs.Signs[i])
+		if err != nil {
+			return err
+		}
+		if !oracle.Verify(key, pub, m, new(big.Int).SetBytes(bs)) {
+			return fmt.Errorf("illegal oracle sign : %d", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Package usr project id.go
+package usr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// TemporaryID returns the temporary contract id.
+func (u *User) TemporaryID() string {
+	if u.dlc == nil {
+		return ""
+	}
+	return hex.EncodeToString(u.dlc.TemporaryID())
+}
+
+// ContractID returns the contract id.
+// It is empty until the fund transaction is fixed.
+func (u *User) ContractID() string {
+	if u.dlc == nil {
+		return ""
+	}
+	return hex.EncodeToString(u.dlc.ContractID())
+}
+
+// contractLabel returns the contract id for log,
+// or the temporary id if the contract id is not fixed.
+func (u *User) contractLabel() string {
+	if id := u.ContractID(); id != "" {
+		return id
+	}
+	return u.TemporaryID()
+}
+
+// offerID returns the temporary contract id, which is the hash of offer with empty id.
+func offerID(odata *OfferData) []byte {
+	tmp := *odata
+	tmp.ID = ""
+	bs, _ := json.Marshal(&tmp)
+	return chainhash.HashB(bs)
+}
+
+// checkIDs compares the temporary and contract ids with own.
+func (u *User) checkIDs(id, cid string) error {
+	if id != u.TemporaryID() {
+		return fmt.Errorf("temporary id mismatch : %s, %s", id, u.TemporaryID())
+	}
+	own := u.dlc.ContractID()
+	bs, err := hex.DecodeString(cid)
+	if err != nil {
+		return err
+	}
+	if own == nil || !bytes.Equal(bs, own) {
+		return fmt.Errorf("contract id mismatch : %s, %x", cid, own)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+// Package usr project rollover.go
+package usr
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+
+	"dlc"
+	"oracle"
+)
+
+// GetRolloverOfferData returns Serialized OfferData of the next contract d,
+// whose fund transaction spends the fund output of the current contract.
+// amount is own amount from the current fund output and the rest is the other's.
+// The settlement and refund transactions of d are signed before the rollover is signed.
+func (u *User) GetRolloverOfferData(d *dlc.Dlc, amount int64) ([]byte, error) {
+	if u.status != StatusWaitSendTx {
+		return nil, fmt.Errorf("illegal status : %d", u.status)
+	}
+	if u.prev != nil {
+		return nil, fmt.Errorf("rollover is not confirmed")
+	}
+	if d == nil {
+		return nil, fmt.Errorf("parameter is nil")
+	}
+	prev := u.dlc
+	amta, amtb := amount, prev.FundAmount()+prev.SettlementFee()-amount
+	if !d.IsA() {
+		amta, amtb = amtb, amta
+	}
+	roll, err := prev.NewRollover(amta, amtb)
+	if err != nil {
+		return nil, err
+	}
+	err = d.SetRollover(roll)
+	if err != nil {
+		return nil, err
+	}
+	u.prev, u.status = prev, StatusNone
+	bs, err := u.GetOfferData(d)
+	if err != nil {
+		u.restorePrev()
+		return nil, err
+	}
+	return bs, nil
+}
+
+// SetRolloverOfferData sets Serialized OfferData of the next contract
+// spending the fund output of the current contract.
+func (u *User) SetRolloverOfferData(data []byte) error {
+	if u.status != StatusWaitSendTx {
+		return fmt.Errorf("illegal status : %d", u.status)
+	}
+	if u.prev != nil {
+		return fmt.Errorf("rollover is not confirmed")
+	}
+	u.prev, u.status = u.dlc, StatusNone
+	err := u.SetOfferData(data)
+	if err != nil {
+		u.restorePrev()
+		return err
+	}
+	return nil
+}
+
+// CancelRollover cancels the rollover offered or received before the rollover is signed,
+// and the current contract is restored.
+func (u *User) CancelRollover() error {
+	switch u.status {
+	case StatusWaitForAccept, StatusCanGetAccept, StatusWaitForSign, StatusCanGetSign:
+	default:
+		return fmt.Errorf("illegal status : %d", u.status)
+	}
+	if u.prev == nil {
+		return fmt.Errorf("not in rollover")
+	}
+	return u.restorePrev()
+}
+
+// ConfirmRollover drops the previous contract after the fund transaction of rollover
+// has the minimum confirmations.
+// Until then, the previous contract is kept to fall back by AbortRollover.
+func (u *User) ConfirmRollover() error {
+	if u.status != StatusWaitSendTx {
+		return fmt.Errorf("illegal status : %d", u.status)
+	}
+	if u.prev == nil {
+		return fmt.Errorf("not in rollover")
+	}
+	txid := u.dlc.FundTx().TxHash()
+	res, err := u.rpc.Request("gettxout", txid.String(), u.dlc.FundVout(), false)
+	if err != nil {
+		return err
+	}
+	if res.Result == nil {
+		return fmt.Errorf("fund transaction of rollover is not confirmed : %v", txid)
+	}
+	txout := &oracle.TxOutResult{}
+	err = res.UnmarshalResult(txout)
+	if err != nil {
+		return err
+	}
+	if txout.Confirmations < int64(u.confs) {
+		return fmt.Errorf("not enough confirmations : %d, %d", txout.Confirmations, u.confs)
+	}
+	u.prev, u.rollSign = nil, nil
+	return u.save()
+}
+
+// AbortRollover falls back to the previous contract while the fund output of it is not spent,
+// which is when the other does not send the fund transaction of rollover.
+// The refund or settlement transaction of the previous contract can be sent after that.
+// If the fund transaction of rollover is sent later, the previous contract cannot be settled.
+func (u *User) AbortRollover() error {
+	if u.status != StatusWaitSendTx {
+		return fmt.Errorf("illegal status : %d", u.status)
+	}
+	if u.prev == nil {
+		return fmt.Errorf("not in rollover")
+	}
+	roll := u.dlc.Rollover()
+	res, err := u.rpc.Request("gettxout", roll.Txid, roll.Vout, true)
+	if err != nil {
+		return err
+	}
+	if res.Result == nil {
+		return fmt.Errorf("previous fund output is spent : %s:%d", roll.Txid, roll.Vout)
+	}
+	fmt.Printf("%s aborts the rollover contract:%s\n", u.name, u.contractLabel())
+	return u.restorePrev()
+}
+
+// restorePrev restores the current contract of rollover.
+func (u *User) restorePrev() error {
+	u.dlc, u.prev = u.prev, nil
+	u.rollSign = nil
+	return u.setStatus(StatusWaitSendTx)
+}
+
+// setRollover checks and sets the rollover of offer by the current contract.
+// Own amount from the current fund output must not be less than the share of it.
+func (u *User) setRollover(roll *dlc.Rollover) error {
+	if roll == nil && u.prev == nil {
+		return nil
+	}
+	if roll == nil || u.prev == nil {
+		return fmt.Errorf("rollover mismatch : %v, %v", roll != nil, u.prev != nil)
+	}
+	err := u.prev.CheckRollover(roll)
+	if err != nil {
+		return err
+	}
+	err = u.prev.CheckRolloverShare(roll, u.prev.IsA())
+	if err != nil {
+		return err
+	}
+	return u.dlc.SetRollover(roll)
+}
+
+// signRolloverTx returns own signature of fund transaction tx spending the current fund output.
+func (u *User) signRolloverTx(tx *wire.MsgTx) ([]byte, error) {
+	prevOuts, err := u.fundPrevOuts(tx)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := u.prev.RolloverSigHash(tx, prevOuts)
+	if err != nil {
+		return nil, err
+	}
+	pub := u.prev.PublicKey(u.prev.IsA())
+	if u.prev.IsTaproot() {
+		return u.wallet.GetSchnorrSignature(hash, pub)
+	}
+	return u.wallet.GetECDSASignature(hash, pub)
+}
+
+// verifyRolloverTx verifies the signature of the other of fund transaction tx
+// spending the current fund output.
+func (u *User) verifyRolloverTx(tx *wire.MsgTx, sign []byte) error {
+	prevOuts, err := u.fundPrevOuts(tx)
+	if err != nil {
+		return err
+	}
+	return u.prev.VerifyRolloverTx(tx, prevOuts, sign, u.prev.PublicKey(!u.prev.IsA()))
+}
+
+// rolloverWitness returns the witness of fund transaction tx spending the current fund output.
+func (u *User) rolloverWitness(tx *wire.MsgTx) (wire.TxWitness, error) {
+	if u.rollSign == nil {
+		return nil, fmt.Errorf("rollover signature is not received")
+	}
+	own, err := u.signRolloverTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	if u.prev.IsA() {
+		return u.prev.FundWitness(own, u.rollSign)
+	}
+	return u.prev.FundWitness(u.rollSign, own)
+}
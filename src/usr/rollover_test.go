@@ -0,0 +1,196 @@
+package usr
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"dlc"
+	"rpc"
+)
+
+// testDlc returns a contract of A or B whose fund transaction is fixed by the keys i and i+1.
+func testDlc(t *testing.T, famta, famtb int64, isA bool, i byte) *dlc.Dlc {
+	t.Helper()
+	d, err := dlc.NewDlc(famta, famtb, 2, 3, isA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j, isA := range []bool{true, false} {
+		_, pub := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{i + byte(j)}, 32))
+		d.SetPublicKey(pub, isA)
+		hash := chainhash.DoubleHashH([]byte{i + byte(j)})
+		txin := wire.NewTxIn(wire.NewOutPoint(&hash, 0), nil, nil)
+		err = d.SetTxInsAndTxOuts([]*dlc.FundTxIn{{Serial: uint64(i)*10 + uint64(j), TxIn: txin}}, nil, isA)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	d.SetTemporaryID(bytes.Repeat([]byte{i}, dlc.IDSize))
+	d.SetFundSerial(uint64(i)*10 + 9)
+	d.SetGameConditions(1000, 1)
+	return d
+}
+
+// testRollover returns the user of B in rollover from the previous contract to the next,
+// where the previous fund output is split by the shares.
+func testRollover(t *testing.T, status int) (*User, *dlc.Dlc) {
+	t.Helper()
+	prev := testDlc(t, 60000, 40000, false, 1)
+	roll, err := prev.NewRollover(prev.RolloverShare(true), prev.RolloverShare(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := testDlc(t, 30000, 70000, false, 3)
+	err = next.SetRollover(roll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := &User{name: "bob", status: status, confs: 1, dlc: next, prev: prev}
+	return u, prev
+}
+
+// testRPC returns the rpc whose gettxout returns result.
+func testRPC(t *testing.T, result interface{}) *rpc.BtcRPC {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &rpc.BtcRPCRequest{}
+		err := json.NewDecoder(r.Body).Decode(req)
+		if err != nil || req.Method != "gettxout" {
+			http.Error(w, "illegal request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(&rpc.Response{Result: result, ID: req.ID})
+	}))
+	t.Cleanup(server.Close)
+	return &rpc.BtcRPC{URL: server.URL}
+}
+
+func TestSetRollover(t *testing.T) {
+	u, prev := testRollover(t, StatusNone)
+	roll := u.dlc.Rollover()
+	u.dlc = testDlc(t, 30000, 70000, false, 3)
+	if u.setRollover(nil) == nil {
+		t.Fatalf("offer without rollover is accepted in rollover")
+	}
+	// The offerer takes a part of the share of B.
+	moved := *roll
+	moved.Amta, moved.Amtb = roll.Amta+1, roll.Amtb-1
+	if u.setRollover(&moved) == nil || u.dlc.Rollover() != nil {
+		t.Fatalf("short share is accepted")
+	}
+	// The offerer gives a part of own share.
+	moved.Amta, moved.Amtb = roll.Amta-1, roll.Amtb+1
+	err := u.setRollover(&moved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.dlc.Rollover() != &moved || u.prev != prev {
+		t.Fatalf("rollover is not set")
+	}
+	u.prev = nil
+	if u.setRollover(roll) == nil {
+		t.Fatalf("rollover is accepted without the current contract")
+	}
+	if u.setRollover(nil) != nil {
+		t.Fatalf("offer without rollover is not accepted")
+	}
+}
+
+func TestRolloverWithoutInputs(t *testing.T) {
+	u, _ := testRollover(t, StatusWaitForSign)
+	// The offerer A adds no txins, and the previous fund output covers the collateral.
+	if u.dlc.FundTxAmount(true) > 0 {
+		t.Fatalf("fund tx amount of A : %d", u.dlc.FundTxAmount(true))
+	}
+	err := u.dlc.SetTxInsAndTxOuts(nil, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.ContractID() == "" {
+		t.Fatalf("contract id is not fixed")
+	}
+	err = u.checkIDs(u.TemporaryID(), u.ContractID())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCancelRollover(t *testing.T) {
+	u, prev := testRollover(t, StatusWaitForSign)
+	err := u.SetStoreDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.rollSign = []byte{0x01}
+	err = u.CancelRollover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.dlc != prev || u.prev != nil || u.rollSign != nil || u.status != StatusWaitSendTx {
+		t.Fatalf("previous contract is not restored : %d", u.status)
+	}
+	if u.CancelRollover() == nil {
+		t.Fatalf("rollover is cancelled twice")
+	}
+	// The restored contract is saved.
+	loaded := &User{}
+	err = loaded.LoadDlc(u.store, u.ContractID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ContractID() != u.ContractID() || loaded.prev != nil || loaded.status != StatusWaitSendTx {
+		t.Fatalf("restored contract is not saved : %d", loaded.status)
+	}
+	u, _ = testRollover(t, StatusWaitSendTx)
+	if u.CancelRollover() == nil {
+		t.Fatalf("rollover is cancelled after signed")
+	}
+}
+
+func TestAbortRollover(t *testing.T) {
+	u, prev := testRollover(t, StatusWaitSendTx)
+	next := u.dlc
+	u.rpc = testRPC(t, nil)
+	if u.AbortRollover() == nil || u.dlc != next || u.prev != prev {
+		t.Fatalf("rollover is aborted after the previous fund output is spent")
+	}
+	u.rpc = testRPC(t, map[string]interface{}{"confirmations": 1})
+	err := u.AbortRollover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.dlc != prev || u.prev != nil || u.status != StatusWaitSendTx {
+		t.Fatalf("previous contract is not restored")
+	}
+	if u.AbortRollover() == nil {
+		t.Fatalf("rollover is aborted twice")
+	}
+}
+
+func TestConfirmRollover(t *testing.T) {
+	u, prev := testRollover(t, StatusWaitSendTx)
+	next := u.dlc
+	u.rpc = testRPC(t, nil)
+	if u.ConfirmRollover() == nil || u.prev != prev {
+		t.Fatalf("rollover is confirmed before sent")
+	}
+	u.rpc = testRPC(t, map[string]interface{}{"confirmations": 0})
+	if u.ConfirmRollover() == nil || u.prev != prev {
+		t.Fatalf("rollover is confirmed in mempool")
+	}
+	u.rpc = testRPC(t, map[string]interface{}{"confirmations": 1})
+	err := u.ConfirmRollover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.dlc != next || u.prev != nil {
+		t.Fatalf("previous contract is kept")
+	}
+}
@@ -0,0 +1,107 @@
+// Package usr project script.go
+package usr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/wire"
+
+	"dlc"
+)
+
+// SetPayoutScript sets the pkScript to receive the payouts of contract.
+// If it is not set, a wallet key other than the fund key is used.
+func (u *User) SetPayoutScript(pkScript []byte) error {
+	err := dlc.CheckPayoutScript(pkScript)
+	if err != nil {
+		return err
+	}
+	u.payoutScript = pkScript
+	return nil
+}
+
+// SetChangeScript sets the pkScript to receive the change of fund transaction.
+// If it is not set, a wallet key other than the fund and payout keys is used.
+func (u *User) SetChangeScript(pkScript []byte) error {
+	err := dlc.CheckPayoutScript(pkScript)
+	if err != nil {
+		return err
+	}
+	u.changeScript = pkScript
+	return nil
+}
+
+// ownScripts returns the payout and change pkScripts not reusing the fund key.
+func (u *User) ownScripts(pub *btcec.PublicKey) ([]byte, []byte) {
+	payoutPub := u.wallet.GetOtherPublicKey(pub)
+	payout := u.payoutScript
+	if payout == nil {
+		payout = u.wallet.P2WPKHpkScript(payoutPub)
+	}
+	change := u.changeScript
+	if change == nil {
+		change = u.wallet.P2WPKHpkScript(u.wallet.GetOtherPublicKey(pub, payoutPub))
+	}
+	return payout, change
+}
+
+// setOtherScripts sets the payout pkScript of the other and checks the change txouts
+// pay to the change pkScript and the txins cover them.
+func (u *User) setOtherScripts(payout, change string,
+	txins []*dlc.FundTxIn, txouts []*dlc.FundTxOut) error {
+	pkScript, err := hex.DecodeString(payout)
+	if err != nil {
+		return err
+	}
+	err = u.dlc.SetPayoutScript(pkScript, !u.dlc.IsA())
+	if err != nil {
+		return err
+	}
+	pkScript, err = hex.DecodeString(change)
+	if err != nil {
+		return err
+	}
+	err = dlc.CheckPayoutScript(pkScript)
+	if err != nil {
+		return err
+	}
+	for _, txout := range txouts {
+		if !bytes.Equal(txout.TxOut.PkScript, pkScript) {
+			return fmt.Errorf("change pkScript mismatch : %d, %x, %x",
+				txout.Serial, txout.TxOut.PkScript, pkScript)
+		}
+	}
+	return u.checkOtherInputs(txins, txouts)
+}
+
+// checkOtherInputs checks the txins of the other cover the collateral and the fee shares,
+// the change txouts and the fee of the txins and the change txouts.
+func (u *User) checkOtherInputs(txins []*dlc.FundTxIn, txouts []*dlc.FundTxOut) error {
+	tx := wire.NewMsgTx(2)
+	for _, txin := range txins {
+		tx.AddTxIn(txin.TxIn)
+	}
+	prevOuts, err := u.fundPrevOuts(tx)
+	if err != nil {
+		return err
+	}
+	total := int64(0)
+	for _, txout := range prevOuts {
+		total += txout.Value
+	}
+	change := int64(0)
+	weight := int64(len(txins)) * dlc.P2WPKHInputWeight()
+	for _, txout := range txouts {
+		change += txout.TxOut.Value
+		weight += dlc.OutputWeight(txout.TxOut.PkScript)
+	}
+	amt := u.dlc.FundTxAmount(!u.dlc.IsA())
+	fee := dlc.WeightToFee(weight, u.dlc.FundEstimateFee())
+	if total < amt+change+fee {
+		return fmt.Errorf("inputs of the other are short : %d, %d, %d, %d", total, amt, change, fee)
+	}
+	return nil
+}
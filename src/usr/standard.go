@@ -0,0 +1,78 @@
+// Package usr project standard.go
+package usr
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+
+	"oracle"
+)
+
+// checkStandard checks the transactions of dlc with the policy of relay before signing.
+func (u *User) checkStandard() error {
+	amt, err := u.fundInputsAmount()
+	if err != nil {
+		return err
+	}
+	err = u.dlc.CheckFundTx(amt)
+	if err != nil {
+		return fmt.Errorf("fund transaction : %v", err)
+	}
+	err = u.dlc.CheckRefundTx()
+	if err != nil {
+		return fmt.Errorf("refund transaction : %v", err)
+	}
+	for _, rate := range u.dlc.Rates() {
+		err = u.dlc.CheckSettlementTx(rate)
+		if err != nil {
+			return fmt.Errorf("settlement transaction %v : %v", rate, err)
+		}
+	}
+	return nil
+}
+
+// fundInputsAmount returns the total value of inputs of fund transaction by own node.
+func (u *User) fundInputsAmount() (int64, error) {
+	prevOuts, err := u.fundPrevOuts(u.dlc.FundTx())
+	if err != nil {
+		return 0, err
+	}
+	total := int64(0)
+	for _, txout := range prevOuts {
+		total += txout.Value
+	}
+	return total, nil
+}
+
+// fundPrevOuts returns the outputs spent by the inputs of fund transaction tx by own node.
+func (u *User) fundPrevOuts(tx *wire.MsgTx) ([]*wire.TxOut, error) {
+	prevOuts := []*wire.TxOut{}
+	for _, txin := range tx.TxIn {
+		op := txin.PreviousOutPoint
+		res, err := u.rpc.Request("gettxout", op.Hash.String(), op.Index, true)
+		if err != nil {
+			return nil, err
+		}
+		if res.Result == nil {
+			return nil, fmt.Errorf("fund input is not found : %v", op)
+		}
+		txout := &oracle.TxOutResult{}
+		err = res.UnmarshalResult(txout)
+		if err != nil {
+			return nil, err
+		}
+		amt, err := btcutil.NewAmount(txout.Value)
+		if err != nil {
+			return nil, err
+		}
+		pkScript, err := hex.DecodeString(txout.ScriptPubKey.Hex)
+		if err != nil {
+			return nil, err
+		}
+		prevOuts = append(prevOuts, wire.NewTxOut(int64(amt), pkScript))
+	}
+	return prevOuts, nil
+}
@@ -0,0 +1,125 @@
+// Package usr project store.go
+package usr
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"dlc"
+)
+
+// storeData is the contract state saved to file.
+type storeData struct {
+	Status int      `json:"status"` // status for dlc
+	Dlc    *dlc.Dlc `json:"dlc"`    // contract
+	// previous contract and signature of rollover until the rollover is confirmed
+	Prev     *dlc.Dlc `json:"prev,omitempty"`
+	Rollsign string   `json:"rollsign,omitempty"`
+}
+
+// SetStoreDir sets the directory to save the contract at each status.
+// The contract is not saved if the directory is empty.
+func (u *User) SetStoreDir(dir string) error {
+	if dir != "" {
+		err := os.MkdirAll(dir, 0700)
+		if err != nil {
+			return err
+		}
+	}
+	u.store = dir
+	return nil
+}
+
+// setStatus sets the status and saves the contract.
+func (u *User) setStatus(status int) error {
+	u.status = status
+	return u.save()
+}
+
+// save saves the contract to the store directory if it is set.
+func (u *User) save() error {
+	if u.store == "" || u.dlc == nil || u.contractLabel() == "" {
+		return nil
+	}
+	_, err := u.SaveDlc(u.store)
+	return err
+}
+
+// SaveDlc saves the contract to the directory and returns the file path.
+// The file is named by the contract id, or by the temporary id until the fund transaction is fixed.
+// The file of the temporary id is removed when the contract id is fixed.
+func (u *User) SaveDlc(dir string) (string, error) {
+	if u.dlc == nil {
+		return "", fmt.Errorf("dlc is nil")
+	}
+	label := u.contractLabel()
+	if label == "" {
+		return "", fmt.Errorf("contract id is not set")
+	}
+	sd := &storeData{Status: u.status, Dlc: u.dlc, Prev: u.prev}
+	sd.Rollsign = hex.EncodeToString(u.rollSign)
+	bs, err := json.Marshal(sd)
+	if err != nil {
+		return "", err
+	}
+	path := contractPath(dir, label)
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, bs, 0600)
+	if err != nil {
+		return "", err
+	}
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return "", err
+	}
+	if id := u.TemporaryID(); id != label {
+		err = os.Remove(contractPath(dir, id))
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+// LoadDlc loads the contract of the contract id or the temporary id from the directory.
+func (u *User) LoadDlc(dir, id string) error {
+	b, err := hex.DecodeString(id)
+	if err != nil || len(b) != dlc.IDSize {
+		return fmt.Errorf("illegal contract id : %s", id)
+	}
+	bs, err := ioutil.ReadFile(contractPath(dir, id))
+	if err != nil {
+		return err
+	}
+	sd := &storeData{}
+	err = dlc.DecodeJSON(bs, sd)
+	if err != nil {
+		return err
+	}
+	if sd.Dlc == nil {
+		return fmt.Errorf("dlc is nil : %s", id)
+	}
+	tmp := &User{dlc: sd.Dlc}
+	if id != tmp.contractLabel() {
+		return fmt.Errorf("contract id mismatch : %s, %s", id, tmp.contractLabel())
+	}
+	rollsign, err := hex.DecodeString(sd.Rollsign)
+	if err != nil {
+		return err
+	}
+	if len(rollsign) == 0 {
+		rollsign = nil
+	}
+	u.dlc, u.prev, u.rollSign = sd.Dlc, sd.Prev, rollsign
+	u.status = sd.Status
+	return nil
+}
+
+// contractPath returns the file path of the contract id.
+func contractPath(dir, id string) string {
+	return filepath.Join(dir, id+".json")
+}
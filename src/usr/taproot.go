@@ -0,0 +1,26 @@
+// Package usr project taproot.go
+package usr
+
+import (
+	"github.com/btcsuite/btcd/wire"
+)
+
+// IsTaproot returns true if the contract is in taproot mode.
+func (u *User) IsTaproot() bool {
+	return u.dlc != nil && u.dlc.IsTaproot()
+}
+
+// signFundTx returns own signature of tx spending fund output.
+// In taproot mode, it is the signature for the fund leaf.
+func (u *User) signFundTx(tx *wire.MsgTx) ([]byte, error) {
+	pub := u.dlc.PublicKey(u.dlc.IsA())
+	if u.dlc.IsTaproot() {
+		hash, err := u.dlc.FundSigHash(tx, false)
+		if err != nil {
+			return nil, err
+		}
+		return u.wallet.GetSchnorrSignature(hash, pub)
+	}
+	amt := u.dlc.FundAmount() + u.dlc.SettlementFee()
+	return u.wallet.GetWitnessSignature(tx, 0, amt, u.dlc.FundScript(), pub)
+}
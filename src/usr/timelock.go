@@ -0,0 +1,31 @@
+// Package usr project timelock.go
+package usr
+
+// chainInfo is the result of getblockchaininfo.
+type chainInfo struct {
+	Blocks     int   `json:"blocks"`
+	Mediantime int64 `json:"mediantime"`
+}
+
+// getChainInfo returns the block count and the median time of own node.
+func (u *User) getChainInfo() (*chainInfo, error) {
+	res, err := u.rpc.Request("getblockchaininfo")
+	if err != nil {
+		return nil, err
+	}
+	info := &chainInfo{}
+	err = res.UnmarshalResult(info)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// checkTimelocks checks the timelocks of contract by own node.
+func (u *User) checkTimelocks() error {
+	info, err := u.getChainInfo()
+	if err != nil {
+		return err
+	}
+	return u.dlc.CheckTimelocks(info.Blocks, info.Mediantime)
+}
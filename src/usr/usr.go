@@ -8,7 +8,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"math"
 	"math/big"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -17,6 +16,8 @@ import (
 	"github.com/btcsuite/btcd/wire"
 
 	"dlc"
+	"ec"
+	"musig2"
 	"oracle"
 	"rpc"
 	"wallet"
@@ -30,6 +31,28 @@ type User struct {
 	params chaincfg.Params // bitcoin network
 	dlc    *dlc.Dlc        // dlc
 	status int             // status for dlc
+	// oracle check
+	confs     int         // confirmation depth of target block
+	evidences []*Evidence // evidences of wrong oracle signatures
+	bondAmt   int64       // minimum amount of oracle bond
+	// own scripts
+	payoutScript []byte // pkScript to receive payouts
+	changeScript []byte // pkScript to receive change
+	// cooperative close
+	closea      int64            // close amount a
+	closeb      int64            // close amount b
+	closeSign   []byte           // signature of close transaction received
+	closeNonce  *musig2.SecNonce // own MuSig2 secret nonce in taproot mode
+	closePnonce []byte           // own MuSig2 public nonce
+	closeOnonce []byte           // MuSig2 public nonce of the other
+	// rollover
+	prev     *dlc.Dlc // current contract spent by the fund transaction
+	rollSign []byte   // signature of rollover received
+	// batch
+	batch   *Batch // coordinator of contracts funded together
+	batched bool   // is the fund transaction batched?
+	// directory to save the contract at each status
+	store string
 }
 
 // Status
@@ -37,10 +60,16 @@ const (
 	StatusNone                = 0
 	StatusWaitForAccept       = 1
 	StatusCanGetSign          = 2
+	StatusCanGetBatch         = 3
 	StatusCanGetAccept        = 10
 	StatusWaitForSign         = 20
+	StatusWaitForBatch        = 21
 	StatusWaitSendTx          = 30
 	StatusCanSendSettlementTx = 31
+	StatusWaitForCloseAccept  = 40
+	StatusCanGetCloseAccept   = 41
+	StatusCanSendCloseTx      = 42
+	StatusWaitForCloseTx      = 43
 )
 
 // NewUser returns a new User.
@@ -50,6 +79,7 @@ func NewUser(name string, params chaincfg.Params, rpc *rpc.BtcRPC) (*User, error
 	user.params = params
 	user.rpc = rpc
 	user.status = StatusNone
+	user.confs = DefaultMinConfirmations
 	// TODO
 	seed := chainhash.DoubleHashB([]byte(user.name))
 	var err error
@@ -77,15 +107,28 @@ func (u *User) GetAddress() string {
 
 // OfferData is the offer dataset.
 type OfferData struct {
-	High   bool     `json:"high"`   // bet high?
-	Amount int64    `json:"amount"` // amount of fund transaction
-	Fefee  int64    `json:"fefee"`  // estimate fee of fund transaction (satoshi/byte)
-	Sefee  int64    `json:"sefee"`  // estimate fee of settlement transaction (satoshi/byte)
-	Height int      `json:"height"` // height of target block
-	Length int      `json:"length"` // length of target message
-	Pubkey string   `json:"pubkey"` // public key
-	Inputs []string `json:"inputs"` // inputs of fund transaction
-	Output string   `json:"output"` // inputs of fund transaction
+	ID       string   `json:"id"`       // temporary contract id
+	High     bool     `json:"high"`     // bet high?
+	Oamount  int64    `json:"oamount"`  // collateral of offerer (satoshi)
+	Aamount  int64    `json:"aamount"`  // collateral of acceptor (satoshi)
+	Fefee    int64    `json:"fefee"`    // estimate fee of fund transaction (satoshi/byte)
+	Sefee    int64    `json:"sefee"`    // estimate fee of settlement transaction (satoshi/byte)
+	Pubkey   string   `json:"pubkey"`   // public key
+	Inputs   []string `json:"inputs"`   // inputs of fund transaction
+	Iserials []uint64 `json:"iserials"` // serial ids of inputs
+	Outputs  []string `json:"outputs"`  // change outputs of fund transaction
+	Oserials []uint64 `json:"oserials"` // serial ids of change outputs
+	Fserial  uint64   `json:"fserial"`  // serial id of fund output
+	Payout   string   `json:"payout"`   // payout pkScript
+	Change   string   `json:"change"`   // change pkScript
+	Table    string   `json:"table"`    // hash of payout table
+	// fee payers and contract descriptor
+	Payers   *dlc.FeePayers  `json:"payers"`
+	Contract *dlc.Descriptor `json:"contract"`
+	// previous fund output in rollover
+	Rollover *dlc.Rollover `json:"rollover,omitempty"`
+	// fund transaction in batch
+	Batch bool `json:"batch,omitempty"`
 }
 
 // GetOfferData returns Serialized OfferData.
@@ -96,44 +139,69 @@ func (u *User) GetOfferData(d *dlc.Dlc) ([]byte, error) {
 	if d == nil {
 		return nil, fmt.Errorf("parameter is nil")
 	}
+	if u.batch != nil && d.Rollover() != nil {
+		return nil, fmt.Errorf("rollover is not batched")
+	}
 	u.dlc = d
+	u.batched = u.batch != nil
+	err := u.checkTimelocks()
+	if err != nil {
+		return nil, err
+	}
 	pub := u.wallet.GetPublicKey()
 	u.dlc.SetPublicKey(pub, u.dlc.IsA())
+	payout, change := u.ownScripts(pub)
+	err = u.dlc.SetPayoutScript(payout, u.dlc.IsA())
+	if err != nil {
+		return nil, err
+	}
 	// find inputs(utxo) and output of fund transaction
 	tx := wire.NewMsgTx(2)
-	amt := half(u.dlc.FundAmount()) + half(u.dlc.SettlementFee()) +
-		half(dlc.DlcFundTxBaseSize*u.dlc.FundEstimateFee())
-	err := u.wallet.FundTx(tx, amt, u.dlc.FundEstimateFee())
+	amt := u.dlc.FundTxAmount(u.dlc.IsA())
+	err = u.wallet.FundTx(tx, amt, u.dlc.FundEstimateFee(), change, u.batchExcludes()...)
 	if err != nil {
 		return nil, err
 	}
-	inputs := []string{}
-	txins := []*wire.TxIn{}
-	for _, txin := range tx.TxIn {
-		op := &txin.PreviousOutPoint
-		inputs = append(inputs, hex.EncodeToString(OpToBs(op)))
-		txins = append(txins, txin)
+	txins, txouts := newFundTxInsAndTxOuts(tx)
+	u.dlc.SetFundSerial(dlc.NewSerialID())
+	err = u.dlc.SetTxInsAndTxOuts(txins, txouts, u.dlc.IsA())
+	if err != nil {
+		return nil, err
+	}
+	err = u.dlc.ValidateOffer()
+	if err != nil {
+		return nil, err
 	}
-	var txout *wire.TxOut
-	output := ""
-	if len(tx.TxOut) > 0 {
-		txout = tx.TxOut[0]
-		output = hex.EncodeToString(TxOutToBs(txout))
+	table, err := u.dlc.PayoutTableHash()
+	if err != nil {
+		return nil, err
 	}
-	u.dlc.SetTxInsAndTxOut(txins, txout, u.dlc.IsA())
 	// serialize
 	odata := &OfferData{}
 	odata.High = d.IsA()
-	odata.Amount = d.FundAmount()
+	odata.Oamount = d.Collateral(d.IsA())
+	odata.Aamount = d.Collateral(!d.IsA())
 	odata.Fefee = d.FundEstimateFee()
 	odata.Sefee = d.SettlementEstimateFee()
-	odata.Height = d.GameHeight()
-	odata.Length = d.GameLength()
 	odata.Pubkey = hex.EncodeToString(pub.SerializeCompressed())
-	odata.Inputs = inputs
-	odata.Output = output
+	odata.Inputs, odata.Iserials = FundTxInsToStrs(txins)
+	odata.Outputs, odata.Oserials = FundTxOutsToStrs(txouts)
+	odata.Fserial = u.dlc.FundSerial()
+	odata.Payout = hex.EncodeToString(payout)
+	odata.Change = hex.EncodeToString(change)
+	odata.Table = hex.EncodeToString(table)
+	odata.Payers = d.FeePayers()
+	odata.Contract = d.Descriptor()
+	odata.Rollover = d.Rollover()
+	odata.Batch = u.batched
+	id := offerID(odata)
+	odata.ID = hex.EncodeToString(id)
+	u.dlc.SetTemporaryID(id)
 	bs, _ := json.Marshal(odata)
-	u.status = StatusWaitForAccept
+	err = u.setStatus(StatusWaitForAccept)
+	if err != nil {
+		return nil, err
+	}
 	return bs, nil
 }
 
@@ -148,36 +216,91 @@ func (u *User) SetOfferData(data []byte) error {
 	if err != nil {
 		return err
 	}
+	id := offerID(&odata)
+	if odata.ID != hex.EncodeToString(id) {
+		return fmt.Errorf("temporary id mismatch : %s, %x", odata.ID, id)
+	}
 	pub, err := StrToPub(odata.Pubkey)
 	if err != nil {
 		return err
 	}
-	txins, txout, err := StrToInputsOutput(odata.Inputs, odata.Output)
+	txins, err := StrsToFundTxIns(odata.Inputs, odata.Iserials)
 	if err != nil {
 		return err
 	}
-	sfee := odata.Sefee * dlc.DlcSettlementTxSize
+	txouts, err := StrsToFundTxOuts(odata.Outputs, odata.Oserials)
+	if err != nil {
+		return err
+	}
+	if odata.Oamount < 0 || odata.Aamount < 0 || odata.Oamount+odata.Aamount <= 0 {
+		return fmt.Errorf("illegal collateral : %d, %d", odata.Oamount, odata.Aamount)
+	}
+	famta, famtb := odata.Oamount, odata.Aamount
+	if !odata.High {
+		famta, famtb = famtb, famta
+	}
 
 	// create Dlc
-	u.dlc, err = dlc.NewDlc(half(odata.Amount), half(odata.Amount),
-		odata.Fefee, odata.Sefee, half(sfee), half(sfee), !odata.High)
+	u.dlc, err = dlc.NewDlc(famta, famtb, odata.Fefee, odata.Sefee, !odata.High)
+	if err != nil {
+		return err
+	}
+	u.dlc.SetTemporaryID(id)
+	err = u.dlc.SetFeePayers(odata.Payers, odata.High)
+	if err != nil {
+		return err
+	}
+	err = u.setRollover(odata.Rollover)
+	if err != nil {
+		return err
+	}
+	if odata.Batch && odata.Rollover != nil {
+		return fmt.Errorf("rollover is not batched")
+	}
+	u.batched = odata.Batch
+	u.dlc.SetFundSerial(odata.Fserial)
+	err = u.dlc.SetTxInsAndTxOuts(txins, txouts, odata.High)
+	if err != nil {
+		return err
+	}
+	err = u.dlc.SetDescriptor(odata.Contract)
+	if err != nil {
+		return err
+	}
+	err = u.checkTimelocks()
 	if err != nil {
 		return err
 	}
-	u.dlc.SetTxInsAndTxOut(txins, txout, odata.High)
-	u.dlc.SetGameConditions(odata.Height, odata.Length)
 	u.dlc.SetPublicKey(pub, odata.High)
-	u.status = StatusCanGetAccept
-	return nil
+	err = u.setOtherScripts(odata.Payout, odata.Change, txins, txouts)
+	if err != nil {
+		return err
+	}
+	err = u.dlc.ValidateOffer()
+	if err != nil {
+		return err
+	}
+	err = u.checkPayoutTable(odata.Table)
+	if err != nil {
+		return err
+	}
+	return u.setStatus(StatusCanGetAccept)
 }
 
 // AcceptData is the accept dataset.
 type AcceptData struct {
-	Pubkey string   `json:"pubkey"` // public key
-	Inputs []string `json:"inputs"` // inputs of fund transaction
-	Output string   `json:"output"` // output of fund transaction
-	Signs  []string `json:"signs"`  // signatures of the settlement transaction
-	Rsign  string   `json:"rsign"`  // signature of the refund transaction
+	ID         string   `json:"id"`         // temporary contract id
+	ContractID string   `json:"contractid"` // contract id
+	Pubkey     string   `json:"pubkey"`     // public key
+	Inputs     []string `json:"inputs"`     // inputs of fund transaction
+	Iserials   []uint64 `json:"iserials"`   // serial ids of inputs
+	Outputs    []string `json:"outputs"`    // change outputs of fund transaction
+	Oserials   []uint64 `json:"oserials"`   // serial ids of change outputs
+	Payout     string   `json:"payout"`     // payout pkScript
+	Change     string   `json:"change"`     // change pkScript
+	Signs      []string `json:"signs"`      // signatures of the settlement transaction
+	Rsign      string   `json:"rsign"`      // signature of the refund transaction
+	Table      string   `json:"table"`      // hash of payout table
 }
 
 // GetAcceptData returns Serialized AcceptData.
@@ -187,69 +310,69 @@ func (u *User) GetAcceptData() ([]byte, error) {
 	}
 	pub := u.wallet.GetPublicKey()
 	u.dlc.SetPublicKey(pub, u.dlc.IsA())
+	payout, change := u.ownScripts(pub)
+	err := u.dlc.SetPayoutScript(payout, u.dlc.IsA())
+	if err != nil {
+		return nil, err
+	}
 
 	// find inputs(utxo) and output of fund transaction
 	tx := wire.NewMsgTx(2)
-	amt := u.dlc.FundAmount() + u.dlc.SettlementFee()
+	amt := u.dlc.FundTxAmount(u.dlc.IsA())
 	fefee := u.dlc.FundEstimateFee()
-	err := u.wallet.FundTx(tx, half(amt)+
-		half(dlc.DlcFundTxBaseSize*u.dlc.FundEstimateFee()), fefee)
+	err = u.wallet.FundTx(tx, amt, fefee, change, u.batchExcludes()...)
 	if err != nil {
 		return nil, err
 	}
-	inputs := []string{}
-	txins := []*wire.TxIn{}
-	for _, txin := range tx.TxIn {
-		op := &txin.PreviousOutPoint
-		inputs = append(inputs, hex.EncodeToString(OpToBs(op)))
-		txins = append(txins, wire.NewTxIn(op, nil, nil))
-	}
-	var txout *wire.TxOut
-	output := ""
-	if len(tx.TxOut) > 0 {
-		txout = tx.TxOut[0]
-		output = hex.EncodeToString(TxOutToBs(txout))
-	}
-	u.dlc.SetTxInsAndTxOut(txins, txout, u.dlc.IsA())
-
-	// create the signatures of the settlement transaction
-	high := !u.dlc.IsA()
-	rates := u.dlc.Rates()
-	signs := []string{}
-	script := u.dlc.FundScript()
-	for _, rate := range rates {
-		stx := u.dlc.SettlementTx(rate, high)
-		if stx == nil {
-			signs = append(signs, "")
-			continue
-		}
-		sign, serr := u.wallet.GetWitnessSignature(stx, 0, amt, script, pub)
-		if serr != nil {
-			return nil, serr
-		}
-		signs = append(signs, hex.EncodeToString(sign))
+	txins, txouts := newFundTxInsAndTxOuts(tx)
+	err = u.dlc.SetTxInsAndTxOuts(txins, txouts, u.dlc.IsA())
+	if err != nil {
+		return nil, err
 	}
-
-	// create the signature of the refund transaction
-	rtx := u.dlc.RefundTx()
-	if tx == nil {
-		return nil, fmt.Errorf("RefundTx is nil")
+	err = u.dlc.Validate()
+	if err != nil {
+		return nil, err
 	}
-	rsign, err := u.wallet.GetWitnessSignature(rtx, 0, amt, script, pub)
+	err = u.checkStandard()
 	if err != nil {
 		return nil, err
 	}
-	u.dlc.SetRefundSign(rsign, u.dlc.IsA())
+
+	// In batch, the transactions are signed after the fund transaction is fixed.
+	var signs []string
+	var rsign []byte
+	if !u.batched {
+		signs, rsign, err = u.signContractTxs()
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// serialize
 	adata := &AcceptData{}
+	adata.ID = u.TemporaryID()
+	adata.ContractID = u.ContractID()
 	adata.Pubkey = hex.EncodeToString(pub.SerializeCompressed())
-	adata.Inputs = inputs
-	adata.Output = output
+	adata.Inputs, adata.Iserials = FundTxInsToStrs(txins)
+	adata.Outputs, adata.Oserials = FundTxOutsToStrs(txouts)
+	adata.Payout = hex.EncodeToString(payout)
+	adata.Change = hex.EncodeToString(change)
 	adata.Signs = signs
 	adata.Rsign = hex.EncodeToString(rsign)
+	table, err := u.dlc.PayoutTableHash()
+	if err != nil {
+		return nil, err
+	}
+	adata.Table = hex.EncodeToString(table)
 	bs, _ := json.Marshal(adata)
-	u.status = StatusWaitForSign
+	status := StatusWaitForSign
+	if u.batched {
+		status = StatusWaitForBatch
+	}
+	err = u.setStatus(status)
+	if err != nil {
+		return nil, err
+	}
 	return bs, nil
 }
 
@@ -264,58 +387,120 @@ func (u *User) SetAcceptData(data []byte) error {
 	if err != nil {
 		return err
 	}
+	err = u.checkPayoutTable(adata.Table)
+	if err != nil {
+		return err
+	}
 	pub, err := StrToPub(adata.Pubkey)
 	if err != nil {
 		return err
 	}
 	u.dlc.SetPublicKey(pub, !u.dlc.IsA())
-	txins, txout, err := StrToInputsOutput(adata.Inputs, adata.Output)
+	txins, err := StrsToFundTxIns(adata.Inputs, adata.Iserials)
+	if err != nil {
+		return err
+	}
+	txouts, err := StrsToFundTxOuts(adata.Outputs, adata.Oserials)
+	if err != nil {
+		return err
+	}
+	err = u.dlc.SetTxInsAndTxOuts(txins, txouts, !u.dlc.IsA())
+	if err != nil {
+		return err
+	}
+	err = u.setOtherScripts(adata.Payout, adata.Change, txins, txouts)
+	if err != nil {
+		return err
+	}
+	err = u.checkIDs(adata.ID, adata.ContractID)
+	if err != nil {
+		return err
+	}
+	err = u.dlc.Validate()
 	if err != nil {
 		return err
 	}
-	u.dlc.SetTxInsAndTxOut(txins, txout, !u.dlc.IsA())
+	if u.batched {
+		return u.setStatus(StatusCanGetBatch)
+	}
+	err = u.verifyContractTxs(adata.Signs, adata.Rsign)
+	if err != nil {
+		return err
+	}
+	return u.setStatus(StatusCanGetSign)
+}
 
+// signContractTxs returns own adaptor signatures of the settlement transactions
+// and the signature of the refund transaction, which is set to the contract.
+func (u *User) signContractTxs() ([]string, []byte, error) {
+	// create the adaptor signatures of the settlement transaction
+	signs := []string{}
+	for _, rate := range u.dlc.Rates() {
+		tx := u.dlc.SettlementTx(rate)
+		sign, err := u.signSettlementTx(tx, rate)
+		if err != nil {
+			return nil, nil, err
+		}
+		signs = append(signs, hex.EncodeToString(sign))
+	}
+
+	// create the signature of the refund transaction
+	rsign, err := u.signFundTx(u.dlc.RefundTx())
+	if err != nil {
+		return nil, nil, err
+	}
+	u.dlc.SetRefundSign(rsign, u.dlc.IsA())
+	return signs, rsign, nil
+}
+
+// verifyContractTxs verifies the adaptor signatures of the settlement transactions
+// and the signature of the refund transaction by the other, which is set to the contract.
+func (u *User) verifyContractTxs(signs []string, refund string) error {
 	// verify the signatures of the settlement transaction
-	err = u.VerifySettlementTxSigns(adata.Signs)
+	err := u.VerifySettlementTxSigns(signs)
 	if err != nil {
 		return err
 	}
 
-	rsign, err := hex.DecodeString(adata.Rsign)
+	rsign, err := hex.DecodeString(refund)
 	if err != nil {
 		return err
 	}
 	// verify signature of the refund transaction
-	err = u.dlc.VerifyRefundTx(rsign, pub)
+	err = u.dlc.VerifyRefundTx(rsign, u.dlc.PublicKey(!u.dlc.IsA()))
 	if err != nil {
 		return err
 	}
 	u.dlc.SetRefundSign(rsign, !u.dlc.IsA())
-	u.status = StatusCanGetSign
 	return nil
 }
 
-// VerifySettlementTxSigns verifies the signatures of settlement transaction.
+// checkPayoutTable compares the hash of payout table with own.
+func (u *User) checkPayoutTable(table string) error {
+	hash, err := u.dlc.PayoutTableHash()
+	if err != nil {
+		return err
+	}
+	if table != hex.EncodeToString(hash) {
+		return fmt.Errorf("payout table mismatch : %s, %x", table, hash)
+	}
+	return nil
+}
+
+// VerifySettlementTxSigns verifies the adaptor signatures of settlement transaction.
 func (u *User) VerifySettlementTxSigns(signs []string) error {
 	rates := u.dlc.Rates()
 	if len(rates) != len(signs) {
 		return fmt.Errorf("size Error : %d, %d", len(rates), len(signs))
 	}
-	high := u.dlc.IsA()
-	pub := u.dlc.PublicKey(!high)
+	pub := u.dlc.PublicKey(!u.dlc.IsA())
 	for i, sign := range signs {
 		rate := rates[i]
-		if sign == "" {
-			if rate.Amount(high) != 0 {
-				return fmt.Errorf("not found sign. rate : %+v", rate)
-			}
-			continue
-		}
 		s, err := hex.DecodeString(sign)
 		if err != nil {
 			return err
 		}
-		err = u.dlc.Verify(rate, high, s, pub)
+		err = u.dlc.Verify(rate, s, pub)
 		if err != nil {
 			return err
 		}
@@ -325,9 +510,12 @@ func (u *User) VerifySettlementTxSigns(signs []string) error {
 
 // SignData is the sign dataset.
 type SignData struct {
-	Ftws  [][]string `json:"ftws"`  // witnesses of the fund transaction
-	Signs []string   `json:"signs"` // signatures of the settlement transaction
-	Rsign string     `json:"rsign"` // signature of the refund transaction
+	ID         string     `json:"id"`         // temporary contract id
+	ContractID string     `json:"contractid"` // contract id
+	Ftws       [][]string `json:"ftws"`       // witnesses of the fund transaction
+	Signs      []string   `json:"signs"`      // signatures of the settlement transaction
+	Rsign      string     `json:"rsign"`      // signature of the refund transaction
+	Rollsign   string     `json:"rollsign"`   // signature of the fund transaction spending previous fund output
 }
 
 // GetSignData returns Serialized SignData.
@@ -335,57 +523,58 @@ func (u *User) GetSignData() ([]byte, error) {
 	if u.status != StatusCanGetSign {
 		return nil, fmt.Errorf("illegal status : %d", u.status)
 	}
-	// create the signatures of the settlement transaction
-	pub := u.dlc.PublicKey(u.dlc.IsA())
-	high := !u.dlc.IsA()
-	rates := u.dlc.Rates()
-	signs := []string{}
-	amt := u.dlc.FundAmount() + u.dlc.SettlementFee()
-	script := u.dlc.FundScript()
-	for _, rate := range rates {
-		tx := u.dlc.SettlementTx(rate, high)
-		if tx == nil {
-			signs = append(signs, "")
-			continue
-		}
-		sign, err := u.wallet.GetWitnessSignature(tx, 0, amt, script, pub)
-		if err != nil {
-			return nil, err
-		}
-		signs = append(signs, hex.EncodeToString(sign))
+	err := u.dlc.Validate()
+	if err != nil {
+		return nil, err
+	}
+	err = u.checkStandard()
+	if err != nil {
+		return nil, err
+	}
+	signs, rsign, err := u.signContractTxs()
+	if err != nil {
+		return nil, err
 	}
 
-	// create the witnesses of the fund transaction
-	tws := []wire.TxWitness{}
+	// create the witnesses of own txins of the fund transaction
 	tx := u.dlc.FundTx()
-	err := u.wallet.SignTx(tx)
+	err = u.wallet.SignTx(tx)
 	if err != nil {
 		return nil, err
 	}
+	own := map[wire.OutPoint]bool{}
+	for _, txin := range u.dlc.FundTxIns(u.dlc.IsA()) {
+		own[txin.PreviousOutPoint] = true
+	}
+	tws := []wire.TxWitness{}
 	for _, txin := range tx.TxIn {
-		if txin.Witness != nil {
+		if own[txin.PreviousOutPoint] {
 			tws = append(tws, txin.Witness)
 		}
 	}
 
-	// create the signature of the refund transaction
-	rtx := u.dlc.RefundTx()
-	if tx == nil {
-		return nil, fmt.Errorf("RefundTx is nil")
-	}
-	rsign, err := u.wallet.GetWitnessSignature(rtx, 0, amt, script, pub)
-	if err != nil {
-		return nil, err
+	// create the signature of the rollover after the settlement and refund transactions are signed
+	var rollsign []byte
+	if u.prev != nil {
+		rollsign, err = u.signRolloverTx(tx)
+		if err != nil {
+			return nil, err
+		}
 	}
-	u.dlc.SetRefundSign(rsign, u.dlc.IsA())
 
 	// serialize
 	sdata := &SignData{}
+	sdata.ID = u.TemporaryID()
+	sdata.ContractID = u.ContractID()
 	sdata.Ftws = TwsToSss(tws)
 	sdata.Signs = signs
 	sdata.Rsign = hex.EncodeToString(rsign)
+	sdata.Rollsign = hex.EncodeToString(rollsign)
 	bs, _ := json.Marshal(sdata)
-	u.status = StatusWaitSendTx
+	err = u.setStatus(StatusWaitSendTx)
+	if err != nil {
+		return nil, err
+	}
 	return bs, nil
 }
 
@@ -400,6 +589,10 @@ func (u *User) SetSignData(data []byte) error {
 	if err != nil {
 		return err
 	}
+	err = u.checkIDs(sdata.ID, sdata.ContractID)
+	if err != nil {
+		return err
+	}
 
 	// witnesses of the fund transaction
 	tws, err := SssToTws(sdata.Ftws)
@@ -413,27 +606,30 @@ func (u *User) SetSignData(data []byte) error {
 	for i := range txins {
 		txins[i].Witness = tws[i]
 	}
-
-	// verify the signatures of the settlement transaction
-	err = u.VerifySettlementTxSigns(sdata.Signs)
+	err = u.dlc.Validate()
 	if err != nil {
 		return err
 	}
 
-	rsign, err := hex.DecodeString(sdata.Rsign)
+	err = u.verifyContractTxs(sdata.Signs, sdata.Rsign)
 	if err != nil {
 		return err
 	}
-	// verify signature of the refund transaction
-	pub := u.dlc.PublicKey(!u.dlc.IsA())
-	err = u.dlc.VerifyRefundTx(rsign, pub)
-	if err != nil {
-		return err
+
+	// verify signature of the rollover
+	if u.prev != nil {
+		rollsign, err := hex.DecodeString(sdata.Rollsign)
+		if err != nil {
+			return err
+		}
+		err = u.verifyRolloverTx(u.dlc.FundTx(), rollsign)
+		if err != nil {
+			return err
+		}
+		u.rollSign = rollsign
 	}
-	u.dlc.SetRefundSign(rsign, !u.dlc.IsA())
 
-	u.status = StatusWaitSendTx
-	return nil
+	return u.setStatus(StatusWaitSendTx)
 }
 
 // SendFundTx sends the fund transaction.
@@ -441,17 +637,31 @@ func (u *User) SendFundTx() error {
 	if u.status != StatusWaitSendTx {
 		return fmt.Errorf("illegal status : %d", u.status)
 	}
+	if u.batched {
+		return fmt.Errorf("fund transaction is sent by the batch")
+	}
 	tx := u.dlc.FundTx()
 	err := u.wallet.SignTx(tx)
 	if err != nil {
 		return err
 	}
+	if u.dlc.Rollover() != nil {
+		if u.prev == nil || u.rollSign == nil {
+			return fmt.Errorf("rollover is sent by the other")
+		}
+		idx := u.dlc.RolloverIndex()
+		tx.TxIn[idx].Witness, err = u.rolloverWitness(tx)
+		if err != nil {
+			return err
+		}
+	}
 	txid, err := u.wallet.SendTx(tx)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%s sends the Fund Transaction :%v\n", u.name, txid)
-	fmt.Printf("txout[%d]: %10d / %x\n", 0, tx.TxOut[0].Value, tx.TxOut[0].PkScript)
+	fmt.Printf("%s sends the Fund Transaction :%v contract:%s\n", u.name, txid, u.contractLabel())
+	vout := u.dlc.FundVout()
+	fmt.Printf("txout[%d]: %10d / %x\n", vout, tx.TxOut[vout].Value, tx.TxOut[vout].PkScript)
 	return nil
 }
 
@@ -471,6 +681,10 @@ func (u *User) SetOracleKeys(data []byte) error {
 	if err != nil {
 		return err
 	}
+	err = u.verifyBond(pub, okeys.Bond)
+	if err != nil {
+		return err
+	}
 	keys := []*btcec.PublicKey{}
 	for _, key := range okeys.Keys {
 		p, err := StrToPub(key)
@@ -493,10 +707,6 @@ func (u *User) SetOracleSigns(data []byte) error {
 	if err != nil {
 		return err
 	}
-	hash, err := chainhash.NewHashFromStr(osigs.Hash)
-	if err != nil {
-		return err
-	}
 	signs := []*big.Int{}
 	for _, sign := range osigs.Signs {
 		bs, e := hex.DecodeString(sign)
@@ -505,7 +715,23 @@ func (u *User) SetOracleSigns(data []byte) error {
 		}
 		signs = append(signs, new(big.Int).SetBytes(bs))
 	}
-	err = u.dlc.SetOracleSigns(hash, signs)
+	if osigs.Cancel {
+		err = u.dlc.SetOracleCancelSigns(signs)
+	} else {
+		hash, e := chainhash.NewHashFromStr(osigs.Hash)
+		if e != nil {
+			return e
+		}
+		e = u.checkOracleHash(hash, &osigs)
+		if e != nil {
+			return e
+		}
+		err = u.dlc.SetOracleSigns(hash, signs)
+	}
+	if err != nil {
+		return err
+	}
+	err = u.save()
 	if err != nil {
 		return err
 	}
@@ -513,11 +739,15 @@ func (u *User) SetOracleSigns(data []byte) error {
 	if rate == nil {
 		return nil
 	}
-	if rate.Amount(u.dlc.IsA()) > u.dlc.FundAmount()/2 {
-		fmt.Printf("%-5s Win  %v\n", u.name, rate)
+	if osigs.Cancel {
+		fmt.Printf("%-5s Cancel contract:%s %v\n", u.name, u.contractLabel(), rate)
+		return nil
+	}
+	if rate.Amount(u.dlc.IsA()) > u.dlc.Collateral(u.dlc.IsA()) {
+		fmt.Printf("%-5s Win  contract:%s %v\n", u.name, u.contractLabel(), rate)
 		return nil
 	}
-	fmt.Printf("%-5s Lose %v\n", u.name, rate)
+	fmt.Printf("%-5s Lose contract:%s %v\n", u.name, u.contractLabel(), rate)
 	return nil
 }
 
@@ -527,69 +757,20 @@ func (u *User) SendSettlementTx() error {
 	if rate == nil {
 		return fmt.Errorf("rate no fix")
 	}
-	sign1 := rate.ReceivedSign()
-	high := u.dlc.IsA()
-	tx := u.dlc.SettlementTx(rate, high)
-	if tx == nil {
-		return fmt.Errorf("no transaction")
-	}
-	pub := u.dlc.PublicKey(high)
-	amt := u.dlc.FundAmount() + u.dlc.SettlementFee()
-	script := u.dlc.FundScript()
-	sign2, err := u.wallet.GetWitnessSignature(tx, 0, amt, script, pub)
-	if err != nil {
-		return err
-	}
-	var witness [][]byte
-	witness = append(witness, []byte{})
-	if high {
-		witness = append(witness, sign2)
-		witness = append(witness, sign1)
-	} else {
-		witness = append(witness, sign1)
-		witness = append(witness, sign2)
-	}
-	witness = append(witness, script)
-	tx.TxIn[0].Witness = witness
-	txid, err := u.wallet.SendTx(tx)
-	if err != nil {
-		return err
-	}
-	fmt.Printf("%s sends the Settlement Transaction : %v\n", u.name, txid)
-	for idx, txin := range tx.TxIn {
-		fmt.Printf("txin [%d]: %v\n", idx, txin.PreviousOutPoint)
-	}
-	for idx, txout := range tx.TxOut {
-		fmt.Printf("txout[%d]: %10d / %x\n", idx, txout.Value, txout.PkScript)
-	}
-	return nil
-}
-
-// SendSettlementTxTo sends the settlement amount to wallet.
-func (u *User) SendSettlementTxTo(efee int64) error {
-	rate := u.dlc.FixedRate()
-	high := u.dlc.IsA()
-	pub := u.dlc.PublicKey(high)
-	pkScript := u.wallet.P2WPKHpkScript(u.wallet.GetPublicKey())
-	tx, amt, script, err := u.dlc.SettlementToTx(rate, high, pkScript, efee)
+	tx := u.dlc.SettlementTx(rate)
+	err := u.dlc.CheckSettlementTx(rate)
 	if err != nil {
 		return err
 	}
-	sign, err := u.wallet.GetWitnessSignaturePlus(
-		tx, 0, amt, script, pub, rate.MessageSign())
+	tx.TxIn[0].Witness, err = u.settlementWitness(tx, rate)
 	if err != nil {
 		return err
 	}
-	var witness [][]byte
-	witness = append(witness, sign)
-	witness = append(witness, []byte{1})
-	witness = append(witness, script)
-	tx.TxIn[0].Witness = witness
 	txid, err := u.wallet.SendTx(tx)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%s forwards the Settlement Transaction : %v\n", u.name, txid)
+	fmt.Printf("%s sends the Settlement Transaction : %v contract:%s\n", u.name, txid, u.contractLabel())
 	for idx, txin := range tx.TxIn {
 		fmt.Printf("txin [%d]: %v\n", idx, txin.PreviousOutPoint)
 	}
@@ -608,7 +789,7 @@ func (u *User) SendRefundTx() error {
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%s sends the Refund Transaction : %v\n", u.name, txid)
+	fmt.Printf("%s sends the Refund Transaction : %v contract:%s\n", u.name, txid, u.contractLabel())
 	for idx, txin := range tx.TxIn {
 		fmt.Printf("txin [%d]: %v\n", idx, txin.PreviousOutPoint)
 	}
@@ -621,13 +802,11 @@ func (u *User) SendRefundTx() error {
 // ClearDlc clear user dlc.
 func (u *User) ClearDlc() {
 	u.dlc = nil
+	u.prev, u.rollSign = nil, nil
+	u.batched = false
 	u.status = StatusNone
 }
 
-func half(value int64) int64 {
-	return int64(math.Ceil(float64(value) / float64(2)))
-}
-
 // Serialize and Deserialize
 
 // MsgTxToBs change transaction to bytes.
@@ -730,37 +909,86 @@ func StrToPub(str string) (*btcec.PublicKey, error) {
 	if err != nil {
 		return nil, err
 	}
-	pub, err := btcec.ParsePubKey(bs, btcec.S256())
+	pub, err := ec.Parse(bs)
 	if err != nil {
 		return nil, err
 	}
 	return pub, nil
 }
 
-// StrToInputsOutput changes string to inputs and output.
-func StrToInputsOutput(inputs []string, output string) ([]*wire.TxIn, *wire.TxOut, error) {
-	txins := []*wire.TxIn{}
-	for _, input := range inputs {
+// newFundTxInsAndTxOuts returns the txins and txouts of tx with new serial ids.
+func newFundTxInsAndTxOuts(tx *wire.MsgTx) ([]*dlc.FundTxIn, []*dlc.FundTxOut) {
+	txins := []*dlc.FundTxIn{}
+	for _, txin := range tx.TxIn {
+		txin = wire.NewTxIn(&txin.PreviousOutPoint, nil, nil)
+		txins = append(txins, &dlc.FundTxIn{Serial: dlc.NewSerialID(), TxIn: txin})
+	}
+	txouts := []*dlc.FundTxOut{}
+	for _, txout := range tx.TxOut {
+		txouts = append(txouts, &dlc.FundTxOut{Serial: dlc.NewSerialID(), TxOut: txout})
+	}
+	return txins, txouts
+}
+
+// FundTxInsToStrs changes txins to strings and serial ids.
+func FundTxInsToStrs(txins []*dlc.FundTxIn) ([]string, []uint64) {
+	inputs := []string{}
+	serials := []uint64{}
+	for _, txin := range txins {
+		inputs = append(inputs, hex.EncodeToString(OpToBs(&txin.TxIn.PreviousOutPoint)))
+		serials = append(serials, txin.Serial)
+	}
+	return inputs, serials
+}
+
+// FundTxOutsToStrs changes txouts to strings and serial ids.
+func FundTxOutsToStrs(txouts []*dlc.FundTxOut) ([]string, []uint64) {
+	outputs := []string{}
+	serials := []uint64{}
+	for _, txout := range txouts {
+		outputs = append(outputs, hex.EncodeToString(TxOutToBs(txout.TxOut)))
+		serials = append(serials, txout.Serial)
+	}
+	return outputs, serials
+}
+
+// StrsToFundTxIns changes strings and serial ids to txins.
+func StrsToFundTxIns(inputs []string, serials []uint64) ([]*dlc.FundTxIn, error) {
+	if len(inputs) != len(serials) {
+		return nil, fmt.Errorf("illegal length %d, %d", len(inputs), len(serials))
+	}
+	txins := []*dlc.FundTxIn{}
+	for i, input := range inputs {
 		bs, err := hex.DecodeString(input)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		op, err := BsToOp(bs)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
-		txins = append(txins, wire.NewTxIn(op, nil, nil))
+		txin := wire.NewTxIn(op, nil, nil)
+		txins = append(txins, &dlc.FundTxIn{Serial: serials[i], TxIn: txin})
 	}
-	var txout *wire.TxOut
-	if output != "" {
+	return txins, nil
+}
+
+// StrsToFundTxOuts changes strings and serial ids to txouts.
+func StrsToFundTxOuts(outputs []string, serials []uint64) ([]*dlc.FundTxOut, error) {
+	if len(outputs) != len(serials) {
+		return nil, fmt.Errorf("illegal length %d, %d", len(outputs), len(serials))
+	}
+	txouts := []*dlc.FundTxOut{}
+	for i, output := range outputs {
 		bs, err := hex.DecodeString(output)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
-		txout, err = BsToTxOut(bs)
+		txout, err := BsToTxOut(bs)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
+		txouts = append(txouts, &dlc.FundTxOut{Serial: serials[i], TxOut: txout})
 	}
-	return txins, txout, nil
+	return txouts, nil
 }
@@ -3,10 +3,10 @@ package wallet
 
 import (
 	"bytes"
+	crand "crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"log"
-	"math/big"
 	"math/rand"
 	"sort"
 	"time"
@@ -21,7 +21,11 @@ import (
 	"github.com/btcsuite/btcutil/hdkeychain"
 
 	"dlc"
+	"ecdsa"
+	"musig2"
 	"rpc"
+	"scalar"
+	"schnorr"
 )
 
 // Wallet is wallet
@@ -125,6 +129,28 @@ func (w *Wallet) GetPublicKey() *btcec.PublicKey {
 	return info.pub
 }
 
+// GetOtherPublicKey returns public key other than excludes for random.
+func (w *Wallet) GetOtherPublicKey(excludes ...*btcec.PublicKey) *btcec.PublicKey {
+	infos := []*Info{}
+	for _, info := range w.infos {
+		excluded := false
+		for _, pub := range excludes {
+			if info.pub.IsEqual(pub) {
+				excluded = true
+			}
+		}
+		if !excluded {
+			infos = append(infos, info)
+		}
+	}
+	if len(infos) == 0 {
+		infos = w.infos
+	}
+	rand.Seed(time.Now().UnixNano())
+	i := rand.Intn(len(infos))
+	return infos[i].pub
+}
+
 // GetAddress returns bech32 address for random.
 func (w *Wallet) GetAddress() string {
 	rand.Seed(time.Now().UnixNano())
@@ -148,8 +174,18 @@ func (w *Wallet) GetBalance() int64 {
 	return total
 }
 
-// FundTx adds inputs to a transaction until amount.
-func (w *Wallet) FundTx(tx *wire.MsgTx, amount, efee int64) error {
+// FundTx adds inputs to a transaction until amount, and the change to changeScript.
+// If amount is not positive, no inputs are added and -amount is the change.
+// The utxos of excludes are not added, which are used by the other transactions.
+func (w *Wallet) FundTx(tx *wire.MsgTx, amount, efee int64, changeScript []byte,
+	excludes ...wire.OutPoint) error {
+	if amount <= 0 {
+		change := -amount - dlc.WeightToFee(dlc.OutputWeight(changeScript), efee)
+		if !dlc.IsDust(change, changeScript) {
+			tx.AddTxOut(wire.NewTxOut(change, changeScript))
+		}
+		return nil
+	}
 	list, err := w.ListUnspent()
 	if err != nil {
 		return err
@@ -159,15 +195,20 @@ func (w *Wallet) FundTx(tx *wire.MsgTx, amount, efee int64) error {
 	addfee := int64(0)
 	for _, utxo := range list {
 		txid, _ := chainhash.NewHashFromStr(utxo.TxID)
+		if isExcluded(wire.NewOutPoint(txid, utxo.Vout), excludes) {
+			continue
+		}
 		outs = append(outs, wire.NewOutPoint(txid, utxo.Vout))
 		a, _ := btcutil.NewAmount(utxo.Amount)
 		total += int64(a)
-		addfee = int64(len(outs)) * dlc.DlcTxInSize * efee
+		weight := int64(len(outs)) * dlc.P2WPKHInputWeight()
+		addfee = dlc.WeightToFee(weight, efee)
 		if amount+addfee <= total {
 			if amount+addfee == total {
 				break
 			}
-			addfee += dlc.DlcTxOutSize * efee
+			weight += dlc.OutputWeight(changeScript)
+			addfee = dlc.WeightToFee(weight, efee)
 			if amount+addfee <= total {
 				break
 			}
@@ -183,11 +224,24 @@ func (w *Wallet) FundTx(tx *wire.MsgTx, amount, efee int64) error {
 		return nil
 	}
 	change := total - (amount + addfee)
-	pkScript := w.P2WPKHpkScript(w.GetPublicKey())
-	tx.AddTxOut(wire.NewTxOut(change, pkScript))
+	if dlc.IsDust(change, changeScript) {
+		// The dust change is folded into fee.
+		return nil
+	}
+	tx.AddTxOut(wire.NewTxOut(change, changeScript))
 	return nil
 }
 
+// isExcluded returns true if op is in excludes.
+func isExcluded(op *wire.OutPoint, excludes []wire.OutPoint) bool {
+	for _, ex := range excludes {
+		if *op == ex {
+			return true
+		}
+	}
+	return false
+}
+
 // SignTx signs the transaction inputs of known utxo.
 func (w *Wallet) SignTx(tx *wire.MsgTx) error {
 	list, err := w.ListUnspent()
@@ -239,23 +293,33 @@ func (w *Wallet) GetWitnessSignature(tx *wire.MsgTx, idx int, amt int64,
 	return w.GetWitnessSignaturePlus(tx, idx, amt, script, pub, nil)
 }
 
-// GetWitnessSignaturePlus returns signature for added private key
+// GetWitnessSignaturePlus returns signature for added private key,
+// where add is 32 bytes big endian.
 func (w *Wallet) GetWitnessSignaturePlus(tx *wire.MsgTx, idx int, amt int64,
-	script []byte, pub *btcec.PublicKey, add *big.Int) ([]byte, error) {
-	var pri *btcec.PrivateKey
-	for _, info := range w.infos {
-		if info.pub.IsEqual(pub) {
-			key, _ := w.extKey.Child(info.idx)
-			pri, _ = key.ECPrivKey()
-		}
+	script []byte, pub *btcec.PublicKey, add []byte) ([]byte, error) {
+	if add != nil && len(add) != 32 {
+		return nil, fmt.Errorf("illegal add size : %d", len(add))
 	}
-	if pri == nil {
-		return nil, fmt.Errorf("unknown public key %x", pub.SerializeCompressed())
+	pri, err := w.privateKey(pub)
+	if err != nil {
+		return nil, err
 	}
 	if add != nil {
-		num := new(big.Int).Mod(new(big.Int).Add(pri.D, add), btcec.S256().N)
-		pri, _ = btcec.PrivKeyFromBytes(btcec.S256(), num.Bytes())
+		// tweaked key = private key + add
+		var k, a scalar.Scalar
+		b := pri.Serialize()
+		k.SetBytes(b)
+		a.SetBytes(add)
+		k.Add(&k, &a)
+		kb := k.Bytes()
+		scalar.ZeroBig(pri.D)
+		pri, _ = btcec.PrivKeyFromBytes(btcec.S256(), kb[:])
+		scalar.Zero(b)
+		scalar.Zero(kb[:])
+		k.Zero()
+		a.Zero()
 	}
+	defer scalar.ZeroBig(pri.D)
 	sighash := txscript.NewTxSigHashes(tx)
 	sign, err := txscript.RawTxInWitnessSignature(tx, sighash, idx, amt, script, txscript.SigHashAll, pri)
 	if err != nil {
@@ -264,6 +328,94 @@ func (w *Wallet) GetWitnessSignaturePlus(tx *wire.MsgTx, idx int, amt int64,
 	return sign, nil
 }
 
+// GetECDSASignature returns DER signature of hash with SIGHASH_ALL
+func (w *Wallet) GetECDSASignature(hash []byte, pub *btcec.PublicKey) ([]byte, error) {
+	pri, err := w.privateKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	defer scalar.ZeroBig(pri.D)
+	sign, err := pri.Sign(hash)
+	if err != nil {
+		return nil, err
+	}
+	return append(sign.Serialize(), byte(txscript.SigHashAll)), nil
+}
+
+// GetSchnorrSignature returns BIP340 signature of hash
+func (w *Wallet) GetSchnorrSignature(hash []byte, pub *btcec.PublicKey) ([]byte, error) {
+	pri, err := w.privateKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	defer scalar.ZeroBig(pri.D)
+	aux, err := newAux()
+	if err != nil {
+		return nil, err
+	}
+	return schnorr.Sign(pri, hash, aux)
+}
+
+// GetAdaptorSignature returns adaptor signature of hash encrypted to point
+func (w *Wallet) GetAdaptorSignature(hash []byte, pub, point *btcec.PublicKey) ([]byte, error) {
+	pri, err := w.privateKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	defer scalar.ZeroBig(pri.D)
+	aux, err := newAux()
+	if err != nil {
+		return nil, err
+	}
+	return schnorr.EncSign(pri, hash, point, aux)
+}
+
+// GetECDSAAdaptorSignature returns ECDSA adaptor signature of hash encrypted to point
+func (w *Wallet) GetECDSAAdaptorSignature(hash []byte, pub, point *btcec.PublicKey) ([]byte, error) {
+	pri, err := w.privateKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	defer scalar.ZeroBig(pri.D)
+	aux, err := newAux()
+	if err != nil {
+		return nil, err
+	}
+	return ecdsa.EncSign(pri, hash, point, aux)
+}
+
+// GetPartialSignature returns MuSig2 partial signature of session with secret nonce
+func (w *Wallet) GetPartialSignature(session *musig2.Session, sec *musig2.SecNonce,
+	pub *btcec.PublicKey) ([]byte, error) {
+	pri, err := w.privateKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	defer scalar.ZeroBig(pri.D)
+	return session.Sign(sec, pri)
+}
+
+// privateKey returns the private key of public key
+func (w *Wallet) privateKey(pub *btcec.PublicKey) (*btcec.PrivateKey, error) {
+	for _, info := range w.infos {
+		if info.pub.IsEqual(pub) {
+			key, _ := w.extKey.Child(info.idx)
+			return key.ECPrivKey()
+		}
+	}
+	return nil, fmt.Errorf("unknown public key %x", pub.SerializeCompressed())
+}
+
+// newAux returns auxiliary random data for signing
+func newAux() ([]byte, error) {
+	aux := make([]byte, 32)
+	_, err := crand.Read(aux)
+	if err != nil {
+		return nil, err
+	}
+	return aux, nil
+}
+
 // SendTx submits transaction to local node and network.
 func (w *Wallet) SendTx(tx *wire.MsgTx) (*chainhash.Hash, error) {
 	buf := &bytes.Buffer{}